@@ -1,38 +1,277 @@
 package audit
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
 	"github.com/luraproject/lura/v2/config"
 )
 
 // Audit audits the received configuration and generates an AuditResult with all the Recommendations
 func Audit(cfg *config.ServiceConfig, ignore, severities []string) (AuditResult, error) {
-	service := Parse(cfg)
+	return AuditWith(cfg, AuditOptions{Ignore: ignore, Severities: severities})
+}
+
+// AuditOptions groups the settings of an audit process
+type AuditOptions struct {
+	// Ignore contains the ids of the rules to skip
+	Ignore []string
+	// Severities contains the severities of the rules to evaluate, in any case
+	Severities []string
+	// Aggregate reports every rule at most once, without locations, instead of
+	// generating a recommendation per offending element
+	Aggregate bool
+	// Dedupe collapses the recommendations sharing the same rule and message. See AuditResult.Dedupe
+	Dedupe bool
+	// Rules contains additional rules, evaluated after the built-in ones. See LoadRules
+	Rules []Rule
+	// StrictSeverities makes the audit fail when Severities contains unknown values. See
+	// ValidateSeverities
+	StrictSeverities bool
+	// Thresholds overrides the limits of the numeric rules. When nil, DefaultThresholds are used
+	Thresholds *Thresholds
+	// FilesBaseDir enables the rules checking that the files referenced by the configuration
+	// exist, resolving the relative paths from it. They access the filesystem, so they are
+	// skipped when it is empty. Use "." for the working directory
+	FilesBaseDir string
+}
+
+// ErrNilConfig is returned when auditing a nil configuration
+var ErrNilConfig = errors.New("audit: nil service configuration")
+
+// AuditWith audits the received configuration with the given options and generates an AuditResult
+// with all the Recommendations. An empty configuration has no recommendations. Use an Auditor to
+// audit several configurations with the same options
+func AuditWith(cfg *config.ServiceConfig, opts AuditOptions) (AuditResult, error) {
+	if cfg == nil {
+		return AuditResult{Recommendations: []Recommendation{}}, ErrNilConfig
+	}
+	a, err := NewAuditorWith(opts)
+	if err != nil {
+		return AuditResult{Recommendations: []Recommendation{}}, err
+	}
+	return a.Audit(cfg)
+}
+
+// AuditService audits an already parsed configuration and generates an AuditResult with all the
+// Recommendations. See Auditor.AuditService
+func AuditService(s *Service, ignore, severities []string) (AuditResult, error) {
+	return NewAuditor(ignore, severities, nil).AuditService(s)
+}
+
+// AuditReader audits the raw JSON configuration read from r and generates an AuditResult with all
+// the Recommendations. See Auditor.AuditReader
+func AuditReader(r io.Reader, ignore, severities []string) (AuditResult, error) {
+	return NewAuditor(ignore, severities, nil).AuditReader(r)
+}
+
+// Auditor audits configurations with a fixed set of options. The rules to evaluate are selected
+// once, when the Auditor is created, so it is cheaper than AuditWith when auditing many
+// configurations. An Auditor is safe for concurrent use
+type Auditor struct {
+	rules          []*Rule
+	skipped        SkippedRules
+	unknownIgnored []string
+	aggregate      bool
+	dedupe         bool
+	filesBaseDir   string
+}
+
+// SkippedRules lists the ids of the rules excluded from an audit by its options, grouped by the
+// reason. A rule in the ignore list is reported as Ignored, whatever its severity
+type SkippedRules struct {
+	Ignored          []string `json:"ignored"`
+	SeverityFiltered []string `json:"severity_filtered"`
+}
+
+// NewAuditor creates an Auditor skipping the rules in ignore and the ones with a severity not in
+// severities. When rules is nil, the built-in rules are evaluated. The rules are used as received,
+// so validate the custom ones with ValidateRuleSet. Use NewAuditorWith for the rest of the options
+func NewAuditor(ignore, severities []string, rules []Rule) *Auditor {
+	if rules == nil {
+		rules = builtinRules()
+	} else {
+		rules = append([]Rule{}, rules...)
+	}
+	return newAuditor(rules, AuditOptions{Ignore: ignore, Severities: severities})
+}
+
+// NewAuditorWith creates an Auditor with the given options. It fails when StrictSeverities is set
+// and Severities contains unknown values, when the Thresholds of the timeout tiers are not
+// increasing or when the additional Rules reuse the id of another rule
+func NewAuditorWith(opts AuditOptions) (*Auditor, error) {
+	if opts.StrictSeverities {
+		if unknown := ValidateSeverities(opts.Severities); len(unknown) > 0 {
+			return nil, fmt.Errorf("audit: unknown severities: %s", strings.Join(unknown, ", "))
+		}
+	}
 
-	res := AuditResult{Recommendations: []Recommendation{}}
+	rules := ruleSet
+	if opts.Thresholds != nil {
+		t := opts.Thresholds.withDefaults()
+		if err := t.validate(); err != nil {
+			return nil, err
+		}
+		rules = newRuleSet(t)
+	}
+	rules = append(append([]Rule{}, rules...), fileRules...)
+	if len(opts.Rules) > 0 {
+		rules = append(rules, opts.Rules...)
+		if err := ValidateRuleSet(rules); err != nil {
+			return nil, err
+		}
+	}
+	return newAuditor(rules, opts), nil
+}
+
+// newAuditor selects the rules to evaluate with the given options. The Auditor keeps pointers to
+// the elements of rules, so the slice must not be shared with the caller
+func newAuditor(rules []Rule, opts AuditOptions) *Auditor {
 	keysToIgnore := map[string]struct{}{}
-	for _, k := range ignore {
+	for _, k := range opts.Ignore {
 		keysToIgnore[k] = struct{}{}
 	}
-	severitiesToCatch := map[string]struct{}{}
-	for _, k := range severities {
-		severitiesToCatch[k] = struct{}{}
+	severitiesToCatch := severitySet(opts.Severities)
+
+	a := &Auditor{
+		rules:          make([]*Rule, 0, len(rules)),
+		unknownIgnored: validateIgnore(rules, opts.Ignore),
+		aggregate:      opts.Aggregate,
+		dedupe:         opts.Dedupe,
+		filesBaseDir:   opts.FilesBaseDir,
+	}
+	for i := range rules {
+		if rules[i].files != nil && opts.FilesBaseDir == "" {
+			continue
+		}
+		if _, ok := keysToIgnore[rules[i].Recommendation.Rule]; ok {
+			a.skipped.Ignored = append(a.skipped.Ignored, rules[i].Recommendation.Rule)
+			continue
+		}
+		if _, ok := severitiesToCatch[strings.ToUpper(rules[i].Recommendation.Severity)]; !ok {
+			a.skipped.SeverityFiltered = append(a.skipped.SeverityFiltered, rules[i].Recommendation.Rule)
+			continue
+		}
+		a.rules = append(a.rules, &rules[i])
+	}
+	return a
+}
+
+// Audit audits the received configuration and generates an AuditResult with all the Recommendations
+func (a *Auditor) Audit(cfg *config.ServiceConfig) (AuditResult, error) {
+	service, err := Parse(cfg)
+	if err != nil {
+		return AuditResult{Recommendations: []Recommendation{}}, err
+	}
+	return a.audit(&service, cfg), nil
+}
+
+// AuditService audits an already parsed configuration, so the same Service can be audited with
+// several Auditors without parsing it every time. The locations are described with the indexes of
+// the endpoints and the async agents, as their names are not part of the Service. For the same
+// reason, the rules checking the files of the configuration never apply
+func (a *Auditor) AuditService(s *Service) (AuditResult, error) {
+	if s == nil {
+		return AuditResult{Recommendations: []Recommendation{}}, ErrNilConfig
+	}
+	return a.audit(s, &config.ServiceConfig{}), nil
+}
+
+// AuditReader decodes the raw JSON configuration read from r with the lura parser, normalizes it
+// and audits it. Unlike Audit, it also reports the problems lost once the configuration is decoded,
+// as the namespaces declared twice in the same extra_config
+func (a *Auditor) AuditReader(r io.Reader) (AuditResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return AuditResult{Recommendations: []Recommendation{}}, err
+	}
+	return a.auditJSON("-", data)
+}
+
+// auditJSON audits a raw JSON configuration. The name is only used to describe the errors
+func (a *Auditor) auditJSON(name string, data []byte) (AuditResult, error) {
+	service, cfg, err := parseJSON(name, data)
+	if err != nil {
+		return AuditResult{Recommendations: []Recommendation{}}, err
+	}
+	return a.audit(&service, &cfg), nil
+}
+
+// Skipped returns the ids of the rules the Auditor does not evaluate because of its options, in
+// evaluation order. A clean report with many skipped rules may only mean that the filters are
+// too strict
+func (a *Auditor) Skipped() SkippedRules {
+	return SkippedRules{
+		Ignored:          append([]string(nil), a.skipped.Ignored...),
+		SeverityFiltered: append([]string(nil), a.skipped.SeverityFiltered...),
+	}
+}
+
+func (a *Auditor) newResult() AuditResult {
+	return AuditResult{
+		Recommendations: []Recommendation{},
+		Stats:           Stats{UnknownIgnored: append([]string(nil), a.unknownIgnored...)},
+	}
+}
+
+// audit evaluates the rules over the service. An empty service has no recommendations
+func (a *Auditor) audit(service *Service, cfg *config.ServiceConfig) AuditResult {
+	res := a.newResult()
+	if isEmptyService(service) {
+		return res
 	}
+	// most of the configurations trigger a fraction of the rules, so this avoids growing the
+	// slice several times without reserving memory for every rule
+	res.Recommendations = make([]Recommendation, 0, len(a.rules)/4+1)
 
-	for i := range ruleSet {
-		if _, ok := keysToIgnore[ruleSet[i].Recommendation.Rule]; ok {
+	for i := range a.rules {
+		if a.rules[i].files != nil {
+			for _, f := range a.rules[i].files(cfg, a.filesBaseDir) {
+				r := a.rules[i].Recommendation
+				if !a.aggregate {
+					r.Location = f
+				}
+				res.Recommendations = append(res.Recommendations, r)
+				if a.aggregate {
+					break
+				}
+			}
 			continue
 		}
 
-		if _, ok := severitiesToCatch[ruleSet[i].Recommendation.Severity]; !ok {
+		if a.aggregate || a.rules[i].Locate == nil {
+			if a.rules[i].Evaluate(service) {
+				res.Recommendations = append(res.Recommendations, a.rules[i].Recommendation)
+			}
 			continue
 		}
 
-		if ruleSet[i].Evaluate(&service) {
-			res.Recommendations = append(res.Recommendations, ruleSet[i].Recommendation)
+		for _, l := range a.rules[i].Locate(service) {
+			r := a.rules[i].Recommendation
+			r.Location = l.describe(cfg)
+			res.Recommendations = append(res.Recommendations, r)
 		}
 	}
 
-	return res, nil
+	if a.dedupe {
+		res = res.Dedupe()
+	}
+
+	return res
+}
+
+// isEmptyService checks if the service has nothing to audit, as the zero Service or the one parsed
+// from an empty configuration: no flags nor settings, no endpoints, no async agents and no
+// extra_config
+func isEmptyService(s *Service) bool {
+	for _, d := range s.Details {
+		if d != 0 {
+			return false
+		}
+	}
+	return len(s.Endpoints) == 0 && len(s.Agents) == 0 && len(s.Components) == 0
 }
 
 const (
@@ -42,11 +281,46 @@ const (
 	SeverityLow      = "LOW"
 )
 
+// AllSeverities lists every severity, from the most to the least severe. Use Severities to get a
+// copy that can be modified
+var AllSeverities = []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}
+
+// Severities returns a copy of AllSeverities
+func Severities() []string {
+	return append([]string(nil), AllSeverities...)
+}
+
+// severityRank sorts the severities, from the least to the most severe
+var severityRank = map[string]int{
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// severitySet returns the set of the received severities in upper case, so they can be matched
+// regardless of the case used by the caller
+func severitySet(severities []string) map[string]struct{} {
+	res := make(map[string]struct{}, len(severities))
+	for _, k := range severities {
+		res[strings.ToUpper(k)] = struct{}{}
+	}
+	return res
+}
+
 // Rule encapsulates a recommendation and an evaluation function that determines if the recommendation
-// applies for a given service definition
+// applies for a given service definition. Rules with a Locate function are able to report every
+// element of the service where the recommendation applies
 type Rule struct {
 	Recommendation Recommendation
 	Evaluate       func(*Service) bool
+	Locate         func(*Service) []Location
+	// files returns the offending files of the configuration, for the rules accessing the
+	// filesystem. See fileRules
+	files func(*config.ServiceConfig, string) []string
+	// rawOnly is set for the rules detecting what the lura parser discards, so they are only
+	// reported when auditing the raw JSON configuration. See fromRawJSON
+	rawOnly bool
 }
 
 // NewRule creates a Rule with the given arguments
@@ -56,110 +330,515 @@ func NewRule(id, severity, msg string, ef func(*Service) bool) Rule {
 			Rule:     id,
 			Severity: severity,
 			Message:  msg,
+			Link:     linkOf(msg),
 		},
 		Evaluate: ef,
 	}
 }
 
-// AuditResult contains all the recommendations and stats generated by the audit process
+// NewLocatedRule creates a Rule reporting a recommendation for every location returned by lf. The
+// rule applies to the service if there is at least one location
+func NewLocatedRule(id, severity, msg string, lf func(*Service) []Location) Rule {
+	r := NewRule(id, severity, msg, func(s *Service) bool { return len(lf(s)) > 0 })
+	r.Locate = lf
+	return r
+}
+
+// withLocations adds the locations returned by lf to a rule created with NewRule. The rule keeps
+// firing only when its Evaluate predicate holds, so locating it does not change when it applies:
+// lf just tells where, and the service is reported when it has nothing to point at
+func withLocations(r Rule, lf func(*Service) []Location) Rule {
+	evaluate := r.Evaluate
+	r.Locate = func(s *Service) []Location {
+		if !evaluate(s) {
+			return nil
+		}
+		if ls := lf(s); len(ls) > 0 {
+			return ls
+		}
+		return []Location{serviceLocation()}
+	}
+	return r
+}
+
+// fromRawJSON marks a rule as only detected in the services parsed from the raw JSON configuration,
+// as with AuditReader and AuditDir. Audit, AuditWith and AuditService never report it, because the
+// initialized configuration has already lost what the rule looks for
+func fromRawJSON(r Rule) Rule {
+	r.rawOnly = true
+	return r
+}
+
+// Location points to the element of the service where a rule applies. Agent, Endpoint and
+// Backend are indexes of the Service slices and they are set to -1 when they do not apply.
+// Detail is an optional description of the offending value
+type Location struct {
+	Agent    int
+	Endpoint int
+	Backend  int
+	Detail   string
+}
+
+func serviceLocation() Location {
+	return Location{Agent: -1, Endpoint: -1, Backend: -1}
+}
+
+func endpointLocation(e int) Location {
+	return Location{Agent: -1, Endpoint: e, Backend: -1}
+}
+
+func backendLocation(e, b int) Location {
+	return Location{Agent: -1, Endpoint: e, Backend: b}
+}
+
+func agentLocation(a int) Location {
+	return Location{Agent: a, Endpoint: -1, Backend: -1}
+}
+
+func agentBackendLocation(a, b int) Location {
+	return Location{Agent: a, Endpoint: -1, Backend: b}
+}
+
+func (l Location) describe(cfg *config.ServiceConfig) string {
+	var res string
+	switch {
+	case l.Endpoint >= 0 && l.Endpoint < len(cfg.Endpoints):
+		e := cfg.Endpoints[l.Endpoint]
+		res = strings.TrimSpace(e.Method + " " + e.Endpoint)
+	case l.Endpoint >= 0:
+		res = fmt.Sprintf("endpoints[%d]", l.Endpoint)
+	case l.Agent >= 0 && l.Agent < len(cfg.AsyncAgents):
+		res = cfg.AsyncAgents[l.Agent].Name
+	case l.Agent >= 0:
+		res = fmt.Sprintf("async_agent[%d]", l.Agent)
+	}
+	if l.Backend >= 0 {
+		res = fmt.Sprintf("%s backend[%d]", res, l.Backend)
+	}
+	if l.Detail != "" {
+		res = strings.TrimSpace(fmt.Sprintf("%s (%s)", res, l.Detail))
+	}
+	return res
+}
+
+// AuditResult contains all the recommendations and stats generated by the audit process. The
+// recommendations follow the evaluation order of the rules (see Catalog), with the custom rules
+// after the built-in ones, and the ones of a located rule follow the order of the endpoints,
+// backends and async agents in the configuration. The JSON encoding of the result is stable: the
+// fields keep their declaration order, the empty optional fields are omitted and an audit without
+// recommendations encodes them as an empty array, never as null. See ResultSchema
 type AuditResult struct {
 	Recommendations []Recommendation `json:"recommendations"`
 	Stats           Stats            `json:"stats"`
 }
 
-// Recommendation maps a rule id with a severity and a message
+// Dedupe returns a copy of the result where the recommendations sharing the same rule id, message and
+// source are collapsed into the first occurrence, keeping the order. The collapsed recommendation
+// counts the occurrences and lists their locations
+func (r AuditResult) Dedupe() AuditResult {
+	res := AuditResult{
+		Recommendations: make([]Recommendation, 0, len(r.Recommendations)),
+		Stats:           r.Stats,
+	}
+	index := map[[3]string]int{}
+	for _, rec := range r.Recommendations {
+		k := [3]string{rec.Rule, rec.Message, rec.Source}
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(res.Recommendations)
+			res.Recommendations = append(res.Recommendations, Recommendation{
+				Rule:     rec.Rule,
+				Severity: rec.Severity,
+				Message:  rec.Message,
+				Link:     rec.Link,
+				Source:   rec.Source,
+			})
+			i = index[k]
+		}
+		res.Recommendations[i].merge(rec)
+	}
+	return res
+}
+
+// Filter returns a copy of the result keeping only the recommendations that an audit with the same
+// ignore and severities lists would report. The Stats are computed for the received ignore list
+func (r AuditResult) Filter(ignore, severities []string) AuditResult {
+	keysToIgnore := map[string]struct{}{}
+	for _, k := range ignore {
+		keysToIgnore[k] = struct{}{}
+	}
+	severitiesToCatch := severitySet(severities)
+
+	res := AuditResult{
+		Recommendations: []Recommendation{},
+		Stats:           Stats{UnknownIgnored: ValidateIgnore(ignore)},
+	}
+	for _, rec := range r.Recommendations {
+		if _, ok := keysToIgnore[rec.Rule]; ok {
+			continue
+		}
+		if _, ok := severitiesToCatch[strings.ToUpper(rec.Severity)]; !ok {
+			continue
+		}
+		res.Recommendations = append(res.Recommendations, rec)
+	}
+	return res
+}
+
+// FailOn returns an error when the result contains recommendations with a severity equal or higher
+// than minSeverity, describing how many of them there are. The deduplicated recommendations count
+// all their occurrences
+func (r AuditResult) FailOn(minSeverity string) error {
+	threshold, ok := severityRank[strings.ToUpper(minSeverity)]
+	if !ok {
+		return fmt.Errorf("unknown severity %q", minSeverity)
+	}
+	minSeverity = strings.ToUpper(minSeverity)
+	total := 0
+	for _, rec := range r.Recommendations {
+		if severityRank[strings.ToUpper(rec.Severity)] < threshold {
+			continue
+		}
+		if rec.Count > 0 {
+			total += rec.Count
+		} else {
+			total++
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d recommendations with severity %s or higher", total, minSeverity)
+}
+
+// ExitCode maps the result to the exit code of a command line tool, following FailOn: 0 when no
+// recommendation has a severity equal or higher than failOn, 1 when some of them do, and 2 when
+// failOn is not a known severity
+func (r AuditResult) ExitCode(failOn string) int {
+	if _, ok := severityRank[strings.ToUpper(failOn)]; !ok {
+		return 2
+	}
+	if r.FailOn(failOn) != nil {
+		return 1
+	}
+	return 0
+}
+
+// Recommendation maps a rule id with a severity and a message. Location is only set when the
+// recommendation refers to a single element of the configuration. Count and Locations are only
+// set for deduplicated recommendations. Source optionally names the audited configuration, see
+// AuditResult.WithSource
 type Recommendation struct {
-	Rule     string `json:"rule"`
-	Severity string `json:"severity"`
-	Message  string `json:"message"`
-}
-
-// Stats is an empty struct that will be completed in the future
-type Stats struct{}
-
-var ruleSet = []Rule{
-	/*
-	   Section 1: Security
-	*/
-	NewRule("1.1.1", SeverityHigh, "Implement more secure alternatives than Basic Auth to protect your data.", hasBasicAuth),
-	NewRule("1.1.2", SeverityMedium, "Implement stateless authorization methods such as JWT to secure your endpoints as opposed to using API keys.", hasApiKeys),
-	NewRule("1.2.1", SeverityHigh, "Prioritize using JWT for endpoint authorization to ensure security.", hasNoJWT),
-
-	/*
-	   Section 2: Service level recommendations
-	*/
-	NewRule("2.1.1", SeverityHigh, "Only allow secure connections (avoid insecure_connections).", hasInsecureConnections),
-	NewRule("2.1.2", SeverityHigh, "Enable TLS or use a terminator in front of KrakenD.", hasNoTLS),
-	NewRule("2.1.3", SeverityCritical, "TLS is configured but its disable flag prevents from using it.", hasTLSDisabled),
-	NewRule("2.1.7", SeverityHigh, "Enable HTTP security header checks (security/http).", hasNoHTTPSecure),
-	NewRule("2.1.8", SeverityHigh, "Avoid clear text communication (h2c).", hasH2C),
-	NewRule("2.1.9", SeverityLow, "Establish secure connections in internal traffic (avoid insecure_connections internally)", hasBackendInsecureConnections),
-	NewRule("2.2.1", SeverityMedium, "Hide the version banner in runtime.", hasNoObfuscatedVersionHeader),
-	NewRule("2.2.2", SeverityHigh, "Enable CORS.", hasNoCORS),
-	NewRule("2.2.3", SeverityHigh, "Avoid passing all input headers to the backend.", hasHeadersWildcard),
-	NewRule("2.2.4", SeverityHigh, "Avoid passing all input query strings to the backend.", hasQueryStringWildcard),
-	NewRule("2.2.5", SeverityLow, "Avoid exposing gRPC server without services declared.", hasEmptyGRPCServer),
-	NewRule("2.3.1", SeverityMedium, "Limit the amount of cacheable content.", hasUnlimitedCache),
-
-	/*
-	   Section 3: Traffic management / rate limits
-	*/
-	NewRule("3.1.1", SeverityLow, "Enable a bot detector.", hasBotdetectorDisabled),
-	NewRule("3.1.2", SeverityHigh, "Implement a rate-limiting strategy and avoid having an All-You-Can-Eat API.", hasNoRatelimit),
-	NewRule("3.1.3", SeverityHigh, "Protect your backends with a circuit breaker.", hasNoCB),
-	NewRule("3.3.1", SeverityLow, "Set timeouts to below 3 seconds for improved performance.", hasTimeoutBiggerThan(3000)),
-	NewRule("3.3.2", SeverityMedium, "Set timeouts to below 5 seconds for improved performance.", hasTimeoutBiggerThan(5000)),
-	NewRule("3.3.3", SeverityHigh, "Set timeouts to below 30 seconds for improved performance.", hasTimeoutBiggerThan(30000)),
-	NewRule("3.3.4", SeverityCritical, "Set timeouts to below 1 minute for improved performance.", hasTimeoutBiggerThan(60000)),
-
-	/*
-	   Section 4 : Telemetry
-	*/
-	NewRule("4.1.1", SeverityMedium, "Implement a telemetry system for collecting metrics for monitoring and troubleshooting.", hasNoMetrics),
-	NewRule("4.1.2", SeverityMedium, "Give your configuration a name for easy identification in metric tracking.", hasTelemetryMissingName),
-	NewRule("4.1.3", SeverityHigh, "Avoid duplicating telemetry options to prevent system overload.", hasSeveralTelemetryComponents),
-	NewRule("4.2.1", SeverityMedium, "Implement a telemetry system for tracing for monitoring and troubleshooting.", hasNoTracing),
-	NewRule("4.3.1", SeverityMedium, "Use the improved logging component for better log parsing.", hasNoLogging),
-	/*
-	   Section 5: Endpoint level audit
-	*/
-	NewRule("5.1.1", SeverityLow, "Follow a RESTful endpoint structure for improved readability and maintainability.", hasRestfulDisabled),
-	NewRule("5.1.2", SeverityLow, "Disable the /__debug/ endpoint for added security.", hasDebugEnabled),
-	NewRule("5.1.3", SeverityLow, "Disable the /__echo/ endpoint for added security.", hasEchoEnabled),
-	NewRule("5.1.4", SeverityLow, "Declare explicit endpoints instead of using wildcards.", hasEndpointWildcard),
-	NewRule("5.1.5", SeverityMedium, "Declare explicit endpoints instead of using /__catchall.", hasEndpointCatchAll),
-	NewRule("5.1.6", SeverityMedium, "Avoid using multiple write methods in endpoint definitions.", hasMultipleUnsafeMethods),
-	NewRule("5.1.7", SeverityMedium, "Avoid using sequential proxy.", hasSequentialProxy),
-	NewRule("5.2.1", SeverityCritical, "Ensure all endpoints have at least one backend for proper functionality.", hasEndpointWithoutBackends),
-	NewRule("5.2.2", SeverityLow, "Benefit from the backend for frontend pattern capabilities.", hasASingleBackendPerEndpoint),
-	NewRule("5.2.3", SeverityLow, "Avoid coupling clients by overusing no-op encoding.", hasAllEndpointsAsNoop),
-
-	/*
-	   Section 6: Async agents.
-	*/
-	NewRule("6.1.1", SeverityLow, "Ensure Async Agents do not start sequentially to avoid overloading the system (+10 agents).", hasSequentialStart),
-
-	/*
-	   Section 7: Deprecations
-	*/
-	// 7.1 Plugin Deprecations:
-	NewRule("7.1.1", SeverityHigh, "Avoid using deprecated plugin virtualhost. Please visit https://www.krakend.io/docs/enterprise/service-settings/virtual-hosts/#upgrading-from-the-old-plugin-before-v24 to upgrade to the new virtualhost.", hasDeprecatedServerPlugin("virtualhost")),
-	NewRule("7.1.2", SeverityHigh, "Avoid using deprecated plugin static-filesystem. Please visit https://www.krakend.io/docs/enterprise/endpoints/serve-static-content/#upgrading-from-the-old-plugin-before-v24 to upgrade to the new static-filesystem.", hasDeprecatedServerPlugin("static-filesystem")),
-	NewRule("7.1.3", SeverityHigh, "Avoid using deprecated plugin basic-auth. Please move your configuration to the namespace auth/basic to use the new component. See: https://www.krakend.io/docs/enterprise/authentication/basic-authentication/ .", hasDeprecatedServerPlugin("basic-auth")),
-	NewRule("7.1.4", SeverityHigh, "Avoid using deprecated plugin wildcard. Please visit https://www.krakend.io/docs/enterprise/endpoints/wildcard/#upgrading-from-the-old-wildcard-plugin-before-v23 to upgrade to the new Wildcard.", hasDeprecatedServerPlugin("wildcard")),
-
-	NewRule("7.1.5", SeverityHigh, "Avoid using deprecated plugin http-proxy. Please visit https://www.krakend.io/docs/enterprise/backends/http-proxy/#migration-from-old-plugin to upgrade to the new options.", hasDeprecatedClientPlugin("http-proxy")),
-	NewRule("7.1.6", SeverityHigh, "Avoid using deprecated plugin static-filesystem. Please visit https://www.krakend.io/docs/enterprise/endpoints/serve-static-content/#upgrading-from-the-old-plugin-before-v24 to upgrade to the new static-filesystem.", hasDeprecatedClientPlugin("static-filesystem")),
-	NewRule("7.1.7", SeverityHigh, "Avoid using deprecated plugin no-redirect. Please visit https://www.krakend.io/docs/enterprise/backends/client-redirect/#migration-from-old-plugin to upgrade to the new options.", hasDeprecatedClientPlugin("no-redirect")),
-
-	NewRule("7.1.8", SeverityHigh, "Avoid using deprecated plugin content-replacer. Please visit https://www.krakend.io/docs/enterprise/endpoints/content-replacer/#migration-from-old-plugin to upgrade to the new options.", hasDeprecatedReqRespPlugin("content-replacer")),
-	NewRule("7.1.9", SeverityHigh, "Avoid using deprecated plugin response-schema-validator. Please visit https://www.krakend.io/docs/enterprise/endpoints/response-schema-validator/#migration-from-old-plugin to upgrade to the new options.", hasDeprecatedReqRespPlugin("response-schema-validator")),
-
-	// 7.2 Component Deprecations
-	NewRule("7.2.1", SeverityHigh, "Avoid using deprecated component telemetry/ganalytics. Please visit https://www.krakend.io/docs/telemetry/opentelemetry/ to upgrade to OpenTelemetry", hasDeprecatedGanalytics),
-	NewRule("7.2.2", SeverityHigh, "Avoid using deprecated component telemetry/instana. Please visit https://www.krakend.io/docs/telemetry/opentelemetry/ to upgrade to OpenTelemetry", hasDeprecatedInstana),
-	NewRule("7.2.3", SeverityHigh, "Avoid using deprecated component telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to upgrade to OpenTelemetry", hasDeprecatedOpenCensus),
-
-	// 7.3 Config field deprectaions
-	NewRule("7.3.1", SeverityMedium, "Avoid using 'private_key' and 'public_key' and use the 'keys' array.", hasDeprecatedTLSPrivPubKey),
+	Rule      string   `json:"rule"`
+	Severity  string   `json:"severity"`
+	Message   string   `json:"message"`
+	Link      string   `json:"link,omitempty"`
+	Location  string   `json:"location,omitempty"`
+	Count     int      `json:"count,omitempty"`
+	Locations []string `json:"locations,omitempty"`
+	Source    string   `json:"source,omitempty"`
+}
+
+func (r *Recommendation) merge(other Recommendation) {
+	if other.Count == 0 {
+		r.Count++
+	} else {
+		r.Count += other.Count
+	}
+	if other.Location != "" {
+		r.Locations = append(r.Locations, other.Location)
+	}
+	r.Locations = append(r.Locations, other.Locations...)
+}
+
+// Stats contains details about the audit process. UnknownIgnored lists the entries of the ignore
+// list not matching any rule
+type Stats struct {
+	UnknownIgnored []string `json:"unknown_ignored,omitempty"`
+}
+
+// ValidateIgnore returns the entries of the ignore list that do not match any known rule id
+func ValidateIgnore(ignore []string) []string {
+	return validateIgnore(builtinRules(), ignore)
+}
+
+// ValidateSeverities returns the entries of the severities list that do not match, in any case,
+// any of the known severities
+func ValidateSeverities(severities []string) []string {
+	var res []string
+	for _, k := range severities {
+		if _, ok := severityRank[strings.ToUpper(k)]; !ok {
+			res = append(res, k)
+		}
+	}
+	return res
+}
+
+func validateIgnore(rules []Rule, ignore []string) []string {
+	known := make(map[string]struct{}, len(rules))
+	for _, r := range rules {
+		known[r.Recommendation.Rule] = struct{}{}
+	}
+
+	var res []string
+	for _, k := range ignore {
+		if _, ok := known[k]; !ok {
+			res = append(res, k)
+		}
+	}
+	return res
+}
+
+// deprecatedServerPlugin describes the rule of a deprecated server plugin: its stable id and the
+// documentation explaining how to replace it. Message, when set, replaces the generated message
+type deprecatedServerPlugin struct {
+	ID          string
+	Link        string
+	Replacement string
+	Message     string
+}
+
+// deprecatedServerPlugins maps the names of the deprecated server plugins to their rules
+var deprecatedServerPlugins = map[string]deprecatedServerPlugin{
+	"virtualhost":       {ID: "7.1.1", Link: "https://www.krakend.io/docs/enterprise/service-settings/virtual-hosts/#upgrading-from-the-old-plugin-before-v24", Replacement: "the new virtualhost"},
+	"static-filesystem": {ID: "7.1.2", Link: "https://www.krakend.io/docs/enterprise/endpoints/serve-static-content/#upgrading-from-the-old-plugin-before-v24", Replacement: "the new static-filesystem"},
+	"basic-auth": {
+		ID:      "7.1.3",
+		Link:    "https://www.krakend.io/docs/enterprise/authentication/basic-authentication/",
+		Message: "Avoid using deprecated plugin basic-auth. Please move your configuration to the namespace auth/basic to use the new component. See: https://www.krakend.io/docs/enterprise/authentication/basic-authentication/ .",
+	},
+	"wildcard":   {ID: "7.1.4", Link: "https://www.krakend.io/docs/enterprise/endpoints/wildcard/#upgrading-from-the-old-wildcard-plugin-before-v23", Replacement: "the new Wildcard"},
+	"jwt-signer": {ID: "7.1.10", Link: "https://www.krakend.io/docs/authorization/jwt-signing/", Replacement: "the new options"},
+	"ip-filter":  {ID: "7.1.11", Link: "https://www.krakend.io/docs/enterprise/throttling/ipfilter/", Replacement: "the new options"},
+}
+
+// deprecatedServerPluginRule returns the rule of the deprecated server plugin with the received
+// name, as declared in deprecatedServerPlugins
+func deprecatedServerPluginRule(name string) Rule {
+	p := deprecatedServerPlugins[name]
+	msg := p.Message
+	if msg == "" {
+		msg = fmt.Sprintf("Avoid using deprecated plugin %s. Please visit %s to upgrade to %s.", name, p.Link, p.Replacement)
+	}
+	return NewRule(p.ID, SeverityHigh, msg, hasDeprecatedServerPlugin(name))
+}
+
+// fileRules are the built-in rules accessing the filesystem. They are not part of the ruleSet, as
+// they inspect the configuration instead of the Service and they are only evaluated when
+// AuditOptions.FilesBaseDir is set
+var fileRules = []Rule{
+	newFileRule("2.1.16", SeverityHigh, "Fix the paths of the TLS certificates and keys, the files do not exist.", missingTLSFiles),
+}
+
+func newFileRule(id, severity, msg string, ff func(*config.ServiceConfig, string) []string) Rule {
+	r := NewRule(id, severity, msg, func(*Service) bool { return false })
+	r.files = ff
+	return r
+}
+
+func init() {
+	if err := ValidateRuleSet(builtinRules()); err != nil {
+		panic(err)
+	}
+}
+
+// ValidateRuleSet checks that every rule of the set has an id and that no id is used more than
+// once, as the ignore lists could not tell the rules apart
+func ValidateRuleSet(rules []Rule) error {
+	seen := make(map[string]struct{}, len(rules))
+	var duplicated []string
+	for i, r := range rules {
+		id := r.Recommendation.Rule
+		if id == "" {
+			return fmt.Errorf("audit: rule #%d without id", i)
+		}
+		if _, ok := seen[id]; ok {
+			duplicated = append(duplicated, id)
+		}
+		seen[id] = struct{}{}
+	}
+	if len(duplicated) > 0 {
+		return fmt.Errorf("audit: duplicated rule ids: %s", strings.Join(duplicated, ", "))
+	}
+	return nil
+}
+
+// builtinRules returns every built-in rule, the ruleSet followed by the fileRules
+func builtinRules() []Rule {
+	return append(ruleSet[:len(ruleSet):len(ruleSet)], fileRules...)
+}
+
+// ruleSet contains the built-in rules with the default thresholds
+var ruleSet = newRuleSet(DefaultThresholds)
+
+// newRuleSet returns the built-in rules, in evaluation order, with the received thresholds
+func newRuleSet(t Thresholds) []Rule {
+	return []Rule{
+		/*
+		   Section 1: Security
+		*/
+		NewRule("1.1.1", SeverityHigh, "Implement more secure alternatives than Basic Auth to protect your data.", hasBasicAuth),
+		NewRule("1.1.2", SeverityMedium, "Implement stateless authorization methods such as JWT to secure your endpoints as opposed to using API keys.", hasApiKeys),
+		NewRule("1.2.1", SeverityHigh, "Prioritize using JWT for endpoint authorization to ensure security.", hasNoJWT),
+		NewLocatedRule("1.2.2", SeverityCritical, "Never accept the 'none' algorithm when validating JWT.", hasWeakJWTAlg(ValidatorAlgNone)),
+		NewLocatedRule("1.2.3", SeverityHigh, "Prefer asymmetric algorithms (RS, ES, PS families) over symmetric HS algorithms when validating JWT.", hasWeakJWTAlg(ValidatorAlgHMAC)),
+		NewLocatedRule("1.2.4", SeverityCritical, "Fetch the JWK over HTTPS (jwk_url) to prevent key substitution attacks.", hasInsecureJWKURL(false)),
+		NewLocatedRule("1.2.5", SeverityLow, "Fetch the JWK over HTTPS (jwk_url), even from local hosts.", hasInsecureJWKURL(true)),
+		NewLocatedRule("1.2.6", SeverityMedium, "Restrict the accepted JWT with issuer or audience checks.", hasJWTWithoutClaimsCheck),
+		NewLocatedRule("1.2.7", SeverityMedium, "Enable the cache of the JWK fetched from a remote jwk_url to avoid fetching the keys on every request.", hasUncachedJWK),
+		NewLocatedRule("1.2.8", SeverityLow, "Add Authorization to the input_headers of the endpoints validating JWT when their backends check the token, otherwise they never receive it.", hasJWTWithoutAuthorizationHeader),
+		NewLocatedRule("1.3.1", SeverityHigh, "Avoid hardcoding credentials in the backend definitions. Inject them from the environment or a secret manager.", hasHardcodedSecrets),
+
+		/*
+		   Section 2: Service level recommendations
+		*/
+		NewRule("2.1.1", SeverityHigh, "Only allow secure connections (avoid insecure_connections).", hasInsecureConnections),
+		NewRule("2.1.2", SeverityHigh, "Enable TLS or use a terminator in front of KrakenD.", hasNoTLS),
+		NewRule("2.1.3", SeverityCritical, "TLS is configured but its disable flag prevents from using it.", hasTLSDisabled),
+		NewRule("2.1.7", SeverityHigh, "Enable HTTP security header checks (security/http).", hasNoHTTPSecure),
+		NewRule("2.1.8", SeverityHigh, "Avoid clear text communication (h2c).", hasH2C),
+		NewLocatedRule("2.1.9", SeverityLow, "Establish secure connections in internal traffic (avoid insecure_connections internally)", hasBackendInsecureConnections),
+		NewRule("2.1.10", SeverityHigh, "Disable the development mode of the HTTP security headers (is_development), as it turns off its protections.", hasSecurityHTTPDevMode),
+		NewLocatedRule("2.1.11", SeverityLow, "Disable the directory_listing of the static-filesystem, it exposes the name of every served file.", hasStaticDirectoryListing),
+		NewRule("2.1.12", SeverityHigh, "Set the TLS min_version to TLS12 or higher and remove the weak cipher_suites.", hasWeakTLS),
+		NewRule("2.1.13", SeverityMedium, "Set enable_mtls to true or remove the ca_certs, as the client certificates are not verified.", hasUnenforcedMTLS),
+		NewRule("2.1.14", SeverityMedium, "Enable HSTS in the HTTP security headers setting a positive sts_seconds.", hasHTTPSecureWithoutHSTS),
+		NewLocatedRule("2.1.15", SeverityLow, "Avoid allow_open_libs in the Lua scripts, as it lets them escape the sandbox.", hasLuaOpenLibs),
+		NewLocatedRule("2.1.17", SeverityLow, "Avoid disable_host_sanitize in the backends, their hosts are used without validation and could be abused to reach unexpected hosts.", hasUnsanitizedBackendHost),
+		NewRule("2.2.1", SeverityMedium, "Hide the version banner in runtime.", hasNoObfuscatedVersionHeader),
+		NewRule("2.2.2", SeverityHigh, "Enable CORS.", hasNoCORS),
+		NewLocatedRule("2.2.3", SeverityHigh, "Avoid passing all input headers to the backend.", hasHeadersWildcard),
+		NewLocatedRule("2.2.4", SeverityHigh, "Avoid passing all input query strings to the backend.", hasQueryStringWildcard),
+		NewRule("2.2.5", SeverityLow, "Avoid exposing gRPC server without services declared.", hasEmptyGRPCServer),
+		NewRule("2.2.6", SeverityHigh, "Avoid allowing credentials in CORS when all origins are allowed.", hasInsecureCORSCredentials),
+		NewRule("2.2.7", SeverityMedium, "Restrict the CORS allow_methods to the methods your API uses.", hasPermissiveCORSMethods),
+		NewLocatedRule("2.2.8", SeverityMedium, "Avoid passing all input query strings from the endpoint to the backend (input_query_strings: [\"*\"] in the backend).", hasBackendQueryStringWildcard),
+		NewRule("2.2.9", SeverityLow, "Set a CORS max_age to let the browsers cache the preflight requests.", hasNoCORSMaxAge),
+		NewRule("2.2.10", SeverityMedium, "Restrict the CORS allow_headers to the headers your API uses, avoiding the wildcard.", hasPermissiveCORSHeaders),
+		NewRule("2.2.11", SeverityMedium, "Disable the gRPC server reflection in production, as it exposes the schema of your services.", hasGRPCReflection),
+		NewLocatedRule("2.2.12", SeverityMedium, "Avoid passing all input headers from the endpoint to the backend (input_headers: [\"*\"] in the backend).", hasBackendHeadersWildcard),
+		NewLocatedRule("2.3.1", SeverityMedium, "Limit the amount of cacheable content.", hasUnlimitedCache),
+		NewLocatedRule("2.3.2", SeverityLow, "Set a cache_ttl longer than the endpoint timeout, or slow responses expire before being cached.", hasCacheTTLBeyondTimeout),
+		NewLocatedRule("2.3.3", SeverityLow, "Avoid caching authenticated responses without the user identity in the cache key (e.g. {JWT.sub} in the url_pattern): cached data can leak across users.", hasAuthEndpointCached),
+		NewLocatedRule("2.3.4", SeverityLow, "Set Cache-Control headers (cache_ttl or modifier/response-headers) when serving static content.", hasStaticWithoutCacheHeaders),
+		NewLocatedRule("2.3.5", SeverityLow, "Consider caching the responses of GET endpoints (cache_ttl, Cache-Control headers or qos/http-cache) to improve performance.", hasUncacheableGET),
+		NewRule("2.4.1", SeverityLow, "Lower max_idle_connections and max_idle_connections_per_host, as very large connection pools can exhaust the available sockets.", hasLargeIdleConnectionPool(t.MaxIdleConnections)),
+		NewLocatedRule("2.4.2", SeverityMedium, "Set the read_timeout, write_timeout and idle_timeout of the server, or slow clients can exhaust the connections.", hasMissingServerTimeouts(true)),
+		NewLocatedRule("2.4.3", SeverityLow, "Complete the server timeouts, some of read_timeout, write_timeout and idle_timeout are missing.", hasMissingServerTimeouts(false)),
+		NewLocatedRule("2.4.4", SeverityLow, "Set a moderate max_header_bytes in the server to limit the size of the request headers.", hasUnboundedHeaderBytes(t.MaxHeaderBytes)),
+		fromRawJSON(NewLocatedRule("2.5.1", SeverityMedium, "Remove the namespaces declared twice in the same extra_config, only the last declaration is applied.", hasDuplicatedNamespace)),
+
+		/*
+		   Section 3: Traffic management / rate limits
+		*/
+		NewRule("3.1.1", SeverityLow, "Enable a bot detector.", hasBotdetectorDisabled),
+		NewRule("3.1.5", SeverityMedium, "The bot detector has no deny list, patterns or cache_size and does not block any bot.", hasIneffectiveBotdetector),
+		withLocations(NewRule("3.1.2", SeverityHigh, "Implement a rate-limiting strategy and avoid having an All-You-Can-Eat API.", hasNoRatelimit), endpointsWithoutRatelimit),
+		withLocations(NewRule("3.1.3", SeverityHigh, "Protect your backends with a circuit breaker.", hasNoCB), endpointsWithoutCB),
+		NewLocatedRule("3.1.4", SeverityLow, "Rate limiting by client IP stores raw IP addresses. Review the privacy requirements of your jurisdiction or use a non-personal key.", hasIPRatelimitWithoutPrivacy),
+		NewLocatedRule("3.1.6", SeverityHigh, "The rate limit has no max_rate or client_max_rate and does not limit anything.", hasIneffectiveRatelimit),
+		NewLocatedRule("3.1.7", SeverityMedium, "Add a client rate limit (client_max_rate and strategy) next to the max_rate, or a single abusive client can consume the whole budget.", hasRouterRatelimitWithoutClientLimit),
+		NewLocatedRule("3.1.8", SeverityMedium, fmt.Sprintf("Review the circuit breaker settings: a max_errors of 0 opens it on the first failure and a timeout above %s keeps the backend unavailable for too long.", humanDuration(t.MaxCircuitBreakerTimeout)), hasMisconfiguredCB(t.MaxCircuitBreakerTimeout)),
+		NewLocatedRule("3.1.9", SeverityLow, "Consider adding authentication or rate limiting to the write endpoints (POST, PUT, PATCH and DELETE), they have no authentication, rate limit or validation configuration.", hasUnprotectedWriteEndpoint),
+		NewLocatedRule("3.2.1", SeverityMedium, "Use an exponential backoff_strategy when retrying backends, or the retries amplify the load during incidents.", hasRetryWithoutBackoff(t.MaxLinearRetries)),
+		NewLocatedRule("3.3.1", SeverityLow, fmt.Sprintf("Set timeouts to below %s for improved performance.", humanDuration(t.TimeoutLow)), hasTimeoutBetween(ms(t.TimeoutLow), ms(t.TimeoutMedium))),
+		NewLocatedRule("3.3.2", SeverityMedium, fmt.Sprintf("Set timeouts to below %s for improved performance.", humanDuration(t.TimeoutMedium)), hasTimeoutBetween(ms(t.TimeoutMedium), ms(t.TimeoutHigh))),
+		NewLocatedRule("3.3.3", SeverityHigh, fmt.Sprintf("Set timeouts to below %s for improved performance.", humanDuration(t.TimeoutHigh)), hasTimeoutBetween(ms(t.TimeoutHigh), ms(t.TimeoutCritical))),
+		NewLocatedRule("3.3.4", SeverityCritical, fmt.Sprintf("Set timeouts to below %s for improved performance.", humanDuration(t.TimeoutCritical)), hasTimeoutBetween(ms(t.TimeoutCritical), 0)),
+		fromRawJSON(NewLocatedRule("3.3.5", SeverityMedium, "Set a timeout in the endpoints aggregating several backends instead of relying on implicit defaults.", hasAggregationWithoutTimeout)),
+		NewLocatedRule("3.3.6", SeverityLow, "Lower the service timeout close to the longest endpoint timeout, a much larger value is never used and hides the intended limit.", hasOversizedServiceTimeout(t.ServiceTimeoutRatio)),
+
+		/*
+		   Section 4 : Telemetry
+		*/
+		NewRule("4.1.1", SeverityMedium, "Implement a telemetry system for collecting metrics for monitoring and troubleshooting.", hasNoMetrics),
+		NewRule("4.1.2", SeverityMedium, "Give your configuration a name for easy identification in metric tracking.", hasTelemetryMissingName),
+		NewRule("4.1.3", SeverityHigh, "Avoid duplicating telemetry options to prevent system overload.", hasSeveralTelemetryComponents),
+		NewRule("4.1.4", SeverityMedium, "Use OpenTelemetry (telemetry/opentelemetry) for metrics and traces instead of the legacy telemetry components.", hasNoOpenTelemetry),
+		NewRule("4.1.5", SeverityMedium, "Remove the duplicated telemetry exporters (several prometheus exporters or OTLP exporters sending to the same collector).", hasDuplicatedTelemetryExporters),
+		NewRule("4.1.6", SeverityLow, "Keep the built-in /__health endpoint enabled or declare a health check endpoint for your monitoring systems.", hasNoHealthCheck),
+		NewRule("4.1.7", SeverityLow, "Set the top level name of the service to identify the gateway, it is independent of the telemetry names (4.1.2).", hasServiceMissingName),
+		NewRule("4.2.1", SeverityMedium, "Implement a telemetry system for tracing for monitoring and troubleshooting.", hasNoTracing),
+		NewRule("4.2.2", SeverityLow, "Lower the trace sample rate, sampling every request is costly at scale.", hasFullTraceSampling),
+		NewRule("4.2.3", SeverityHigh, "Send the telemetry data over a secure connection, some exporters target non-local collectors in clear text (http:// or insecure).", hasInsecureTelemetryExporter),
+		NewRule("4.3.1", SeverityMedium, "Use the improved logging component for better log parsing.", hasNoLogging),
+		NewRule("4.3.2", SeverityMedium, "Avoid logging to stdout at DEBUG level in production, it is noisy and can leak sensitive data.", hasDebugLogging),
+		/*
+		   Section 5: Endpoint level audit
+		*/
+		NewRule("5.1.1", SeverityLow, "Follow a RESTful endpoint structure for improved readability and maintainability.", hasRestfulDisabled),
+		NewRule("5.1.2", SeverityLow, "Disable the /__debug/ endpoint for added security.", hasDebugEnabled),
+		NewRule("5.1.3", SeverityLow, "Disable the /__echo/ endpoint for added security.", hasEchoEnabled),
+		NewLocatedRule("5.1.4", SeverityLow, "Declare explicit endpoints instead of using wildcards.", hasEndpointWildcard),
+		NewLocatedRule("5.1.5", SeverityMedium, "Declare explicit endpoints instead of using /__catchall.", hasEndpointCatchAll),
+		NewLocatedRule("5.1.6", SeverityMedium, "Avoid using multiple write methods in endpoint definitions.", hasMultipleUnsafeMethods),
+		NewLocatedRule("5.1.7", SeverityMedium, "Avoid using sequential proxy.", hasSequentialProxy),
+		NewLocatedRule("5.1.8", SeverityLow, "Forward the Content-Type header in write endpoints declaring input_headers.", hasMissingContentTypeForward),
+		NewLocatedRule("5.1.9", SeverityMedium, "Declare explicit methods instead of using the wildcard method (*).", hasWildcardMethod),
+		NewLocatedRule("5.1.10", SeverityMedium, "Avoid declaring endpoints with overlapping paths for the same method, one of them shadows the other.", hasOverlappingEndpoints),
+		NewLocatedRule("5.1.11", SeverityMedium, "Avoid calling the backend with the GET method from write endpoints (POST, PUT or PATCH), the request body is dropped.", hasWriteEndpointWithGETBackend),
+		NewLocatedRule("5.1.12", SeverityLow, "Set return_error_details or return_error_code in the backends of sequential endpoints to know which step of the chain failed.", hasSequentialProxyWithoutErrorDetails),
+		fromRawJSON(NewLocatedRule("5.1.13", SeverityMedium, "Declare the method of the wildcard and /__catchall endpoints, it defaults to GET and may not be the intended one.", hasCatchAllWithoutMethod)),
+		fromRawJSON(NewLocatedRule("5.1.14", SeverityLow, "Remove the empty endpoints, their backends declare no url_pattern nor any configuration.", hasEmptyEndpoint)),
+		NewLocatedRule("5.2.1", SeverityCritical, "Ensure all endpoints have at least one backend for proper functionality.", hasEndpointWithoutBackends),
+		NewRule("5.2.2", SeverityLow, "Benefit from the backend for frontend pattern capabilities.", hasASingleBackendPerEndpoint),
+		NewRule("5.2.3", SeverityLow, "Avoid coupling clients by overusing no-op encoding.", hasAllEndpointsAsNoop),
+		NewLocatedRule("5.2.4", SeverityLow, "Spread the backends of aggregated endpoints across different hosts to avoid a single failure domain.", hasSingleFailureDomain),
+		NewLocatedRule("5.2.5", SeverityLow, "Lower the concurrent_calls of the endpoint, every concurrent call multiplies the load on the backends.", hasHighConcurrentCalls(t.MaxConcurrentCalls)),
+		NewLocatedRule("5.2.6", SeverityLow, "Reduce the number of backends aggregated by the endpoint, every backend adds latency and a point of failure.", hasTooManyBackends(t.MaxBackendsPerEndpoint)),
+		NewLocatedRule("5.2.7", SeverityMedium, "Remove the response manipulations of the endpoints using the no-op encoding, as the response is passed through without processing them.", hasNoopWithTransformations),
+		NewLocatedRule("5.2.8", SeverityMedium, "Declare a routable host for every backend, 0.0.0.0 or an empty list cannot be reached.", hasUnroutableBackendHost),
+		NewLocatedRule("5.2.9", SeverityLow, "Set disable_host_sanitize to true in the backends using the DNS SRV service discovery (sd: dns).", hasMisconfiguredDNSSD),
+		NewLocatedRule("5.2.10", SeverityLow, "Filter the responses of the lambda, pub/sub and AMQP consumer backends (allow, deny or mapping) to avoid leaking internal fields.", hasUnfilteredSensitiveConnector),
+		NewLocatedRule("5.2.11", SeverityLow, "Avoid the string encoding in the backends of aggregated endpoints, their responses cannot be merged into a JSON object.", hasAggregatedStringBackend),
+		NewLocatedRule("5.2.12", SeverityLow, "Use the same encoding in the endpoint and its backends, a no-op backend cannot produce the declared output_encoding.", hasEncodingMismatch),
+		NewLocatedRule("5.3.1", SeverityLow, "Disable auto_ack in the AMQP consumers, the failed messages are acknowledged and never reach a dead-letter exchange.", hasAutoAckConsumer),
+
+		/*
+		   Section 6: Async agents.
+		*/
+		NewRule("6.1.1", SeverityLow, "Ensure Async Agents do not start sequentially to avoid overloading the system (+10 agents).", hasSequentialStart),
+		NewLocatedRule("6.1.2", SeverityLow, "Set an idempotency key (msg_id_key) when async agents publish the consumed messages to avoid duplicate amplification.", hasNonIdempotentAgentPipeline),
+		NewLocatedRule("6.1.3", SeverityCritical, "Ensure all async agents have at least one backend to forward the consumed messages.", hasAsyncAgentWithoutBackend),
+		NewLocatedRule("6.1.4", SeverityMedium, "Limit the consumer of the async agents with a max_rate and a moderate number of workers, or a burst of messages can overwhelm the backends.", hasUnboundedAgentConsumer(t.MaxAgentWorkers)),
+		NewLocatedRule("6.1.5", SeverityLow, "Set a backoff_strategy in the connection of the async agents to avoid tight reconnection loops when the broker fails.", hasAgentWithoutBackoff),
+		NewLocatedRule("6.1.6", SeverityMedium, "Give every async agent a unique name, duplicated names produce confusing metrics and logs.", hasDuplicatedAgentName),
+
+		/*
+		   Section 7: Deprecations
+		*/
+		// 7.1 Plugin Deprecations (the server plugins are declared at deprecatedServerPlugins):
+		deprecatedServerPluginRule("virtualhost"),
+		deprecatedServerPluginRule("static-filesystem"),
+		deprecatedServerPluginRule("basic-auth"),
+		deprecatedServerPluginRule("wildcard"),
+
+		NewLocatedRule("7.1.5", SeverityHigh, "Avoid using deprecated plugin http-proxy. Please visit https://www.krakend.io/docs/enterprise/backends/http-proxy/#migration-from-old-plugin to upgrade to the new options.", hasDeprecatedClientPlugin("http-proxy")),
+		NewLocatedRule("7.1.6", SeverityHigh, "Avoid using deprecated plugin static-filesystem. Please visit https://www.krakend.io/docs/enterprise/endpoints/serve-static-content/#upgrading-from-the-old-plugin-before-v24 to upgrade to the new static-filesystem.", hasDeprecatedClientPlugin("static-filesystem")),
+		NewLocatedRule("7.1.7", SeverityHigh, "Avoid using deprecated plugin no-redirect. Please visit https://www.krakend.io/docs/enterprise/backends/client-redirect/#migration-from-old-plugin to upgrade to the new options.", hasDeprecatedClientPlugin("no-redirect", "http-client-no-redirect")),
+
+		NewLocatedRule("7.1.8", SeverityHigh, "Avoid using deprecated plugin content-replacer. Please visit https://www.krakend.io/docs/enterprise/endpoints/content-replacer/#migration-from-old-plugin to upgrade to the new options.", hasDeprecatedReqRespPlugin("content-replacer")),
+		NewLocatedRule("7.1.9", SeverityHigh, "Avoid using deprecated plugin response-schema-validator. Please visit https://www.krakend.io/docs/enterprise/endpoints/response-schema-validator/#migration-from-old-plugin to upgrade to the new options.", hasDeprecatedReqRespPlugin("response-schema-validator")),
+		deprecatedServerPluginRule("jwt-signer"),
+		deprecatedServerPluginRule("ip-filter"),
+		NewRule("7.1.12", SeverityHigh, "Avoid declaring the deprecated virtualhost plugin together with the virtualhost component (server/virtualhost), the precedence between them is undefined.", hasMixedVirtualhost),
+
+		// 7.2 Component Deprecations
+		NewRule("7.2.1", SeverityHigh, "Avoid using deprecated component telemetry/ganalytics. Please visit https://www.krakend.io/docs/telemetry/opentelemetry/ to upgrade to OpenTelemetry", hasDeprecatedGanalytics(false)),
+		NewRule("7.2.2", SeverityHigh, "Avoid using deprecated component telemetry/instana. Please visit https://www.krakend.io/docs/telemetry/opentelemetry/ to upgrade to OpenTelemetry", hasDeprecatedInstana),
+		NewRule("7.2.3", SeverityHigh, "Avoid using deprecated component telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to upgrade to OpenTelemetry", hasDeprecatedOpenCensus),
+		NewRule("7.2.4", SeverityHigh, "Avoid using the deprecated logger exporter of telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to send the data to an OpenTelemetry collector with an otlp exporter", hasDeprecatedOpenCensusExporter(OpenCensusLogger)),
+		NewRule("7.2.5", SeverityHigh, "Avoid using the deprecated zipkin exporter of telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to send the traces to Zipkin through an otlp exporter of telemetry/opentelemetry", hasDeprecatedOpenCensusExporter(OpenCensusZipkin)),
+		NewRule("7.2.6", SeverityHigh, "Avoid using the deprecated jaeger exporter of telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to send the traces to Jaeger with an otlp exporter of telemetry/opentelemetry", hasDeprecatedOpenCensusExporter(OpenCensusJaeger)),
+		NewRule("7.2.7", SeverityHigh, "Avoid using the deprecated influxdb exporter of telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to send the metrics to InfluxDB through an otlp exporter of telemetry/opentelemetry", hasDeprecatedOpenCensusExporter(OpenCensusInfluxDB)),
+		NewRule("7.2.8", SeverityHigh, "Avoid using the deprecated prometheus exporter of telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to use the prometheus exporter of telemetry/opentelemetry", hasDeprecatedOpenCensusExporter(OpenCensusPrometheus)),
+		NewRule("7.2.9", SeverityHigh, "Avoid using the deprecated xray exporter of telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to send the traces to AWS X-Ray through an otlp exporter of telemetry/opentelemetry", hasDeprecatedOpenCensusExporter(OpenCensusXRay)),
+		NewRule("7.2.10", SeverityHigh, "Avoid using the deprecated stackdriver exporter of telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to send the data to Google Cloud through an otlp exporter of telemetry/opentelemetry", hasDeprecatedOpenCensusExporter(OpenCensusStackdriver)),
+		NewRule("7.2.11", SeverityHigh, "Avoid using the deprecated datadog exporter of telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to send the data to Datadog through an otlp exporter of telemetry/opentelemetry", hasDeprecatedOpenCensusExporter(OpenCensusDatadog)),
+		NewRule("7.2.12", SeverityHigh, "Avoid using the deprecated ocagent exporter of telemetry/opencensus. Please visit https://www.krakend.io/docs/telemetry/opencensus/#transition-from-opencensus to replace the OpenCensus agent with an otlp exporter of telemetry/opentelemetry", hasDeprecatedOpenCensusExporter(OpenCensusOCAgent)),
+		NewRule("7.2.13", SeverityLow, "Remove the deprecated component telemetry/ganalytics, OpenTelemetry is already configured. Please visit https://www.krakend.io/docs/telemetry/opentelemetry/ to move the remaining metrics to it", hasDeprecatedGanalytics(true)),
+
+		// 7.3 Config field deprectaions
+		NewRule("7.3.1", SeverityMedium, "Avoid using 'private_key' and 'public_key' and use the 'keys' array.", hasDeprecatedTLSPrivPubKey),
+	}
 }
@@ -24,7 +24,11 @@ func ExampleAudit() {
 	}
 
 	for i, r := range result.Recommendations {
-		fmt.Printf("%02d: %s %s  \t%s\n", i, r.Rule, r.Severity, r.Message)
+		fmt.Printf("%02d: %s %s  \t%s", i, r.Rule, r.Severity, r.Message)
+		if r.Location != "" {
+			fmt.Printf(" [%s]", r.Location)
+		}
+		fmt.Println()
 	}
 
 	// output:
@@ -33,21 +37,63 @@ func ExampleAudit() {
 	// 02: 2.1.8 HIGH  	Avoid clear text communication (h2c).
 	// 03: 2.2.1 MEDIUM  	Hide the version banner in runtime.
 	// 04: 2.2.2 HIGH  	Enable CORS.
-	// 05: 2.2.3 HIGH  	Avoid passing all input headers to the backend.
-	// 06: 2.2.4 HIGH  	Avoid passing all input query strings to the backend.
-	// 07: 2.3.1 MEDIUM  	Limit the amount of cacheable content.
-	// 08: 3.1.3 HIGH  	Protect your backends with a circuit breaker.
-	// 09: 3.3.2 MEDIUM  	Set timeouts to below 5 seconds for improved performance.
-	// 10: 3.3.3 HIGH  	Set timeouts to below 30 seconds for improved performance.
-	// 11: 3.3.4 CRITICAL  	Set timeouts to below 1 minute for improved performance.
-	// 12: 4.1.1 MEDIUM  	Implement a telemetry system for collecting metrics for monitoring and troubleshooting.
-	// 13: 4.1.3 HIGH  	Avoid duplicating telemetry options to prevent system overload.
-	// 14: 4.3.1 MEDIUM  	Use the improved logging component for better log parsing.
-	// 15: 5.1.5 MEDIUM  	Declare explicit endpoints instead of using /__catchall.
-	// 16: 5.1.6 MEDIUM  	Avoid using multiple write methods in endpoint definitions.
-	// 17: 5.1.7 MEDIUM  	Avoid using sequential proxy.
-	// 18: 7.1.3 HIGH  	Avoid using deprecated plugin basic-auth. Please move your configuration to the namespace auth/basic to use the new component. See: https://www.krakend.io/docs/enterprise/authentication/basic-authentication/ .
-	// 19: 7.1.7 HIGH  	Avoid using deprecated plugin no-redirect. Please visit https://www.krakend.io/docs/enterprise/backends/client-redirect/#migration-from-old-plugin to upgrade to the new options.
-	// 20: 7.3.1 MEDIUM  	Avoid using 'private_key' and 'public_key' and use the 'keys' array.
+	// 05: 2.2.3 HIGH  	Avoid passing all input headers to the backend. [GET /wildcarded/resource/*]
+	// 06: 2.2.4 HIGH  	Avoid passing all input query strings to the backend. [GET /wildcarded/resource/*]
+	// 07: 2.3.1 MEDIUM  	Limit the amount of cacheable content. [GET /protected/resource backend[0]]
+	// 08: 2.4.2 MEDIUM  	Set the read_timeout, write_timeout and idle_timeout of the server, or slow clients can exhaust the connections. [(missing: read_timeout, write_timeout, idle_timeout)]
+	// 09: 3.1.3 HIGH  	Protect your backends with a circuit breaker. [GET /protected/resource]
+	// 10: 3.1.3 HIGH  	Protect your backends with a circuit breaker. [GET /wildcarded/resource/*]
+	// 11: 3.1.3 HIGH  	Protect your backends with a circuit breaker. [GET /ws]
+	// 12: 3.1.3 HIGH  	Protect your backends with a circuit breaker. [GET /cached]
+	// 13: 3.1.3 HIGH  	Protect your backends with a circuit breaker. [GET /__catchall]
+	// 14: 3.3.2 MEDIUM  	Set timeouts to below 5 seconds for improved performance. [GET /wildcarded/resource/* (timeout: 10s)]
+	// 15: 3.3.2 MEDIUM  	Set timeouts to below 5 seconds for improved performance. [GET /__catchall (timeout: 10s)]
+	// 16: 3.3.4 CRITICAL  	Set timeouts to below 1 minute for improved performance. [GET /protected/resource (timeout: 2m20s)]
+	// 17: 4.1.1 MEDIUM  	Implement a telemetry system for collecting metrics for monitoring and troubleshooting.
+	// 18: 4.1.3 HIGH  	Avoid duplicating telemetry options to prevent system overload.
+	// 19: 4.1.5 MEDIUM  	Remove the duplicated telemetry exporters (several prometheus exporters or OTLP exporters sending to the same collector).
+	// 20: 4.3.1 MEDIUM  	Use the improved logging component for better log parsing.
+	// 21: 5.1.5 MEDIUM  	Declare explicit endpoints instead of using /__catchall. [GET /__catchall]
+	// 22: 5.1.6 MEDIUM  	Avoid using multiple write methods in endpoint definitions. [GET /__catchall]
+	// 23: 5.1.7 MEDIUM  	Avoid using sequential proxy. [GET /__catchall]
+	// 24: 5.2.8 MEDIUM  	Declare a routable host for every backend, 0.0.0.0 or an empty list cannot be reached. [GET /protected/resource backend[0]]
+	// 25: 5.2.8 MEDIUM  	Declare a routable host for every backend, 0.0.0.0 or an empty list cannot be reached. [GET /wildcarded/resource/* backend[0]]
+	// 26: 5.2.8 MEDIUM  	Declare a routable host for every backend, 0.0.0.0 or an empty list cannot be reached. [GET /ws backend[0]]
+	// 27: 5.2.8 MEDIUM  	Declare a routable host for every backend, 0.0.0.0 or an empty list cannot be reached. [GET /cached backend[0]]
+	// 28: 5.2.8 MEDIUM  	Declare a routable host for every backend, 0.0.0.0 or an empty list cannot be reached. [GET /__catchall backend[0]]
+	// 29: 5.2.8 MEDIUM  	Declare a routable host for every backend, 0.0.0.0 or an empty list cannot be reached. [GET /__catchall backend[1]]
+	// 30: 5.2.8 MEDIUM  	Declare a routable host for every backend, 0.0.0.0 or an empty list cannot be reached. [GET /__catchall backend[2]]
+	// 31: 7.1.3 HIGH  	Avoid using deprecated plugin basic-auth. Please move your configuration to the namespace auth/basic to use the new component. See: https://www.krakend.io/docs/enterprise/authentication/basic-authentication/ .
+	// 32: 7.1.7 HIGH  	Avoid using deprecated plugin no-redirect. Please visit https://www.krakend.io/docs/enterprise/backends/client-redirect/#migration-from-old-plugin to upgrade to the new options. [GET /wildcarded/resource/*]
+	// 33: 7.3.1 MEDIUM  	Avoid using 'private_key' and 'public_key' and use the 'keys' array.
 
 }
+
+func ExampleNewAuditor() {
+	a := NewAuditor([]string{"1.1.1", "1.1.2"}, []string{SeverityCritical}, nil)
+
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	cfg.Normalize()
+
+	for i, c := range []*config.ServiceConfig{&cfg, {}} {
+		result, err := a.Audit(c)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("config %d: %d recommendations\n", i, len(result.Recommendations))
+		for _, r := range result.Recommendations {
+			fmt.Printf("  %s %s [%s]\n", r.Rule, r.Severity, r.Location)
+		}
+	}
+
+	// output:
+	// config 0: 2 recommendations
+	//   2.1.3 CRITICAL []
+	//   3.3.4 CRITICAL [GET /protected/resource (timeout: 2m20s)]
+	// config 1: 0 recommendations
+}
@@ -1,9 +1,17 @@
 package audit
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
+	cb "github.com/krakendio/krakend-circuitbreaker/v2/gobreaker"
 	"github.com/luraproject/lura/v2/config"
+	server "github.com/luraproject/lura/v2/transport/http/server/plugin"
 )
 
 func TestAudit_all(t *testing.T) {
@@ -20,16 +28,21 @@ func TestAudit_all(t *testing.T) {
 			"2.2.3",
 			"2.2.4",
 			"2.3.1",
+			"2.3.3", // -- the JWT protected endpoint has a cached backend
+			"2.4.2", // the server has no read, write and idle timeouts
+			"2.4.4", // the server has no max_header_bytes
 			"3.1.1",
 			// "3.1.2", -- we added service level rate limit
 			"3.1.3",
-			"3.3.1",
+			// "3.3.1", "3.3.3" -- every endpoint is only reported by the tier of its timeout
 			"3.3.2",
-			"3.3.3",
 			"3.3.4",
 			"4.1.1",
 			"4.1.3", // -- we have prometheus and otel metrics
+			"4.1.5", // -- both otlp exporters send traces to example.com
+			"4.1.7", // the service has no top level name
 			// "4.2.1", -- opentelemetryis enabled for tracing
+			"4.2.2", // -- trace_sample_rate is 1
 			"4.3.1",
 			"5.1.1",
 			"5.1.2",
@@ -38,12 +51,15 @@ func TestAudit_all(t *testing.T) {
 			"5.1.5",
 			"5.1.6",
 			"5.1.7",
+			"5.1.12", // the sequential endpoint does not return the error details
 			// "5.2.2", -- we added multiple backends to the test to check for multiple unsafe methods
+			"5.2.8", // the backends have no host
 			"7.1.3", // deprecated server plugin basic auth
 			"7.1.7", // deprecated client plugin no-redirect
 			"7.3.1", // deprecated TLS private_key and public_key
 		},
-		levels: []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow},
+		levels:    []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow},
+		aggregate: true,
 	}
 	testAudit(t, tc)
 }
@@ -60,16 +76,21 @@ func TestAudit_exclude(t *testing.T) {
 			"2.2.3",
 			"2.2.4",
 			"2.3.1",
+			"2.3.3", // -- the JWT protected endpoint has a cached backend
+			"2.4.2", // the server has no read, write and idle timeouts
+			"2.4.4", // the server has no max_header_bytes
 			"3.1.1",
 			// "3.1.2", -- add added service level rate limit
 			"3.1.3",
-			"3.3.1",
+			// "3.3.1", "3.3.3" -- every endpoint is only reported by the tier of its timeout
 			"3.3.2",
-			"3.3.3",
 			"3.3.4",
 			"4.1.1",
 			"4.1.3", // -- we have prometheus and otel metrics
+			"4.1.5", // -- both otlp exporters send traces to example.com
+			"4.1.7", // the service has no top level name
 			// "4.2.1", -- opentelemetry is enabled for tracing
+			"4.2.2", // -- trace_sample_rate is 1
 			"4.3.1",
 			"5.1.1",
 			"5.1.2",
@@ -78,13 +99,16 @@ func TestAudit_exclude(t *testing.T) {
 			"5.1.5",
 			"5.1.6",
 			"5.1.7",
+			"5.1.12", // the sequential endpoint does not return the error details
 			// "5.2.2", -- we added multiple backends to the test to check for multiple unsafe methods
+			"5.2.8", // the backends have no host
 			"7.1.3", // deprecated plugin basic-auth
 			"7.1.7", // deprecated client plugin no-redirect
 			"7.3.1", // deprecated TLS private_key and public_key
 		},
-		exclude: []string{"1.1.1", "1.1.2"},
-		levels:  []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow},
+		exclude:   []string{"1.1.1", "1.1.2"},
+		levels:    []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow},
+		aggregate: true,
 	}
 	testAudit(t, tc)
 }
@@ -95,15 +119,72 @@ func TestAudit_severity(t *testing.T) {
 			"2.1.3",
 			"3.3.4",
 		},
-		levels: []string{SeverityCritical},
+		levels:    []string{SeverityCritical},
+		aggregate: true,
 	}
 	testAudit(t, tc)
 }
 
+func TestAudit_severityCase(t *testing.T) {
+	tc := testCase{
+		expectedRecommendations: []string{
+			"2.1.3",
+			"3.3.4",
+		},
+		levels:    []string{"critical"},
+		aggregate: true,
+	}
+	testAudit(t, tc)
+
+	tc.levels = []string{"Critical"}
+	testAudit(t, tc)
+}
+
+func TestAudit_locations(t *testing.T) {
+	tc := testCase{
+		expectedRecommendations: []string{
+			"2.1.3",
+			"3.3.2",
+			"3.3.2",
+			"3.3.4",
+		},
+		expectedLocations: []string{
+			"",
+			"GET /wildcarded/resource/* (timeout: 10s)",
+			"GET /__catchall (timeout: 10s)",
+			"GET /protected/resource (timeout: 2m20s)",
+		},
+		levels: []string{SeverityCritical, SeverityHigh, SeverityMedium},
+		exclude: []string{
+			"1.1.1", "1.1.2", "2.1.7", "2.1.8", "2.2.1", "2.2.2", "2.2.3", "2.2.4", "2.3.1", "2.4.2", "3.1.3",
+			"4.1.1", "4.1.3", "4.1.5", "4.3.1", "5.1.5", "5.1.6", "5.1.7", "5.2.8", "7.1.3", "7.1.7", "7.3.1",
+		},
+	}
+	testAudit(t, tc)
+}
+
+func Test_withLocations(t *testing.T) {
+	r := withLocations(NewRule("3.1.3", SeverityHigh, "", hasNoCB), endpointsWithoutCB)
+	withCB := Endpoint{Components: Component{cb.Namespace: []int{}}}
+
+	// the located rule applies in the same cases as its Evaluate predicate
+	if ls := r.Locate(&Service{Endpoints: []Endpoint{withCB, {}}}); len(ls) > 0 {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+	if ls := r.Locate(&Service{Endpoints: []Endpoint{{}, {}}}); !reflect.DeepEqual(ls, []Location{endpointLocation(0), endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+	if ls := r.Locate(&Service{}); !reflect.DeepEqual(ls, []Location{serviceLocation()}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
 type testCase struct {
 	expectedRecommendations []string
+	expectedLocations       []string
 	exclude                 []string
 	levels                  []string
+	aggregate               bool
 }
 
 func testAudit(t *testing.T, tc testCase) {
@@ -113,7 +194,7 @@ func testAudit(t *testing.T, tc testCase) {
 	}
 	cfg.Normalize()
 
-	result, err := Audit(&cfg, tc.exclude, tc.levels)
+	result, err := AuditWith(&cfg, AuditOptions{Ignore: tc.exclude, Severities: tc.levels, Aggregate: tc.aggregate})
 	if err != nil {
 		t.Error(err)
 		return
@@ -132,5 +213,665 @@ func testAudit(t *testing.T, tc testCase) {
 		if result.Recommendations[i].Rule != id {
 			t.Errorf("unexpected rule %d: %s", i, result.Recommendations[i].Rule)
 		}
+		if tc.aggregate && result.Recommendations[i].Location != "" {
+			t.Errorf("unexpected location %d: %s", i, result.Recommendations[i].Location)
+		}
+		if i < len(tc.expectedLocations) && result.Recommendations[i].Location != tc.expectedLocations[i] {
+			t.Errorf("unexpected location %d: %q", i, result.Recommendations[i].Location)
+		}
+	}
+}
+
+func TestAuditResult_Dedupe(t *testing.T) {
+	r := AuditResult{Recommendations: []Recommendation{
+		{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Location: "GET /a"},
+		{Rule: "2.1.3", Severity: SeverityCritical, Message: "bar"},
+		{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Location: "GET /b"},
+		{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Location: "GET /c"},
+	}}
+
+	res := r.Dedupe()
+	expected := []Recommendation{
+		{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Count: 3, Locations: []string{"GET /a", "GET /b", "GET /c"}},
+		{Rule: "2.1.3", Severity: SeverityCritical, Message: "bar", Count: 1},
+	}
+	if !reflect.DeepEqual(res.Recommendations, expected) {
+		t.Errorf("unexpected result: %+v", res.Recommendations)
+	}
+
+	if len(r.Recommendations) != 4 {
+		t.Error("the original result has been modified")
+	}
+
+	if again := res.Dedupe(); !reflect.DeepEqual(again.Recommendations, expected) {
+		t.Errorf("dedupe is not idempotent: %+v", again.Recommendations)
+	}
+}
+
+func TestAuditResult_Filter(t *testing.T) {
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	cfg.Normalize()
+
+	all, err := Audit(&cfg, []string{}, []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ignore := []string{"1.1.1", "3.1.3", "foo"}
+	severities := []string{SeverityHigh, SeverityCritical}
+	want, err := Audit(&cfg, ignore, severities)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if res := all.Filter(ignore, severities); !reflect.DeepEqual(res, want) {
+		t.Errorf("unexpected result:\nhave: %+v\nwant: %+v", res, want)
+	}
+	if res := all.Filter(ignore, []string{"high", "Critical"}); !reflect.DeepEqual(res, want) {
+		t.Errorf("unexpected result with mixed-case severities:\nhave: %+v\nwant: %+v", res, want)
+	}
+	if len(all.Recommendations) <= len(want.Recommendations) {
+		t.Error("the filter did not remove any recommendation")
+	}
+}
+
+func TestAuditResult_FailOn(t *testing.T) {
+	r := AuditResult{Recommendations: []Recommendation{
+		{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Count: 3, Locations: []string{"GET /a", "GET /b", "GET /c"}},
+		{Rule: "2.1.3", Severity: SeverityCritical, Message: "bar"},
+		{Rule: "2.2.1", Severity: SeverityMedium, Message: "baz"},
+	}}
+
+	for severity, want := range map[string]string{
+		SeverityCritical: "1 recommendations with severity CRITICAL or higher",
+		SeverityHigh:     "4 recommendations with severity HIGH or higher",
+		SeverityLow:      "5 recommendations with severity LOW or higher",
+		"high":           "4 recommendations with severity HIGH or higher",
+		"Critical":       "1 recommendations with severity CRITICAL or higher",
+		"foo":            `unknown severity "foo"`,
+	} {
+		if err := r.FailOn(severity); err == nil || err.Error() != want {
+			t.Errorf("%s: unexpected error: %v", severity, err)
+		}
+	}
+
+	if err := (AuditResult{Recommendations: r.Recommendations[2:]}).FailOn(SeverityHigh); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (AuditResult{}).FailOn(SeverityLow); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAuditResult_ExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		found  string
+		failOn map[string]int
+	}{
+		{found: SeverityCritical, failOn: map[string]int{SeverityCritical: 1, SeverityHigh: 1, SeverityMedium: 1, SeverityLow: 1}},
+		{found: SeverityHigh, failOn: map[string]int{SeverityCritical: 0, SeverityHigh: 1, SeverityMedium: 1, SeverityLow: 1}},
+		{found: SeverityMedium, failOn: map[string]int{SeverityCritical: 0, SeverityHigh: 0, SeverityMedium: 1, SeverityLow: 1}},
+		{found: SeverityLow, failOn: map[string]int{SeverityCritical: 0, SeverityHigh: 0, SeverityMedium: 0, "low": 1}},
+	} {
+		r := AuditResult{Recommendations: []Recommendation{{Rule: "1.1.1", Severity: tc.found}}}
+		for failOn, want := range tc.failOn {
+			if code := r.ExitCode(failOn); code != want {
+				t.Errorf("%s finding, failing on %s: unexpected exit code %d", tc.found, failOn, code)
+			}
+		}
+	}
+
+	if code := (AuditResult{}).ExitCode(SeverityLow); code != 0 {
+		t.Errorf("unexpected exit code for a clean result: %d", code)
+	}
+	if code := (AuditResult{}).ExitCode("foo"); code != 2 {
+		t.Errorf("unexpected exit code for an unknown severity: %d", code)
+	}
+}
+
+func TestAuditWith_dedupe(t *testing.T) {
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	cfg.Normalize()
+
+	result, err := AuditWith(&cfg, AuditOptions{Ignore: []string{}, Severities: []string{SeverityHigh}, Dedupe: true})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, r := range result.Recommendations {
+		if r.Rule != "3.1.3" {
+			continue
+		}
+		if r.Count != 5 || len(r.Locations) != 5 {
+			t.Errorf("unexpected deduplicated recommendation: %+v", r)
+		}
+		return
+	}
+	t.Error("rule 3.1.3 not found")
+}
+
+func TestAudit_nilConfig(t *testing.T) {
+	res, err := Audit(nil, []string{}, []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow})
+	if err != ErrNilConfig {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(res.Recommendations) > 0 {
+		t.Errorf("unexpected recommendations: %+v", res.Recommendations)
+	}
+
+	if _, err := Parse(nil); err != ErrNilConfig {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAudit_emptyConfig(t *testing.T) {
+	res, err := Audit(&config.ServiceConfig{}, []string{"foo"}, []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if res.Recommendations == nil || len(res.Recommendations) > 0 {
+		t.Errorf("unexpected recommendations: %+v", res.Recommendations)
+	}
+	if !reflect.DeepEqual(res.Stats.UnknownIgnored, []string{"foo"}) {
+		t.Errorf("unexpected stats: %+v", res.Stats)
+	}
+
+	// the parsed empty configuration is as empty as the zero Service
+	s, err := Parse(&config.ServiceConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuditor(nil, []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}, nil)
+	for _, s := range []*Service{&s, {}} {
+		if res := a.audit(s, &config.ServiceConfig{}); len(res.Recommendations) > 0 {
+			t.Errorf("unexpected result: %+v", res)
+		}
+	}
+}
+
+func TestValidateIgnore(t *testing.T) {
+	if res := ValidateIgnore([]string{"1.1.1", "2.2.2"}); len(res) > 0 {
+		t.Errorf("unexpected unknown ids: %v", res)
+	}
+
+	if res := ValidateIgnore([]string{"1.1.1", "2.2.22", "foo"}); !reflect.DeepEqual(res, []string{"2.2.22", "foo"}) {
+		t.Errorf("unexpected unknown ids: %v", res)
+	}
+}
+
+func TestSeverities(t *testing.T) {
+	res := Severities()
+	if !reflect.DeepEqual(res, []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}) {
+		t.Errorf("unexpected severities: %v", res)
+	}
+	res[0] = "foo"
+	if AllSeverities[0] != SeverityCritical {
+		t.Error("the returned slice is not a copy")
+	}
+	if len(AllSeverities) != len(severityRank) || len(ValidateSeverities(AllSeverities)) > 0 {
+		t.Error("AllSeverities and the ranks are out of sync")
+	}
+}
+
+func TestValidateSeverities(t *testing.T) {
+	if res := ValidateSeverities([]string{SeverityCritical, "high", "Medium", "LOW"}); len(res) > 0 {
+		t.Errorf("unexpected unknown severities: %v", res)
+	}
+	if res := ValidateSeverities([]string{"HIHG", SeverityLow, "info"}); !reflect.DeepEqual(res, []string{"HIHG", "info"}) {
+		t.Errorf("unexpected unknown severities: %v", res)
+	}
+}
+
+func TestAuditWith_strictSeverities(t *testing.T) {
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	cfg.Normalize()
+
+	severities := []string{SeverityCritical, "HIHG", "info"}
+	if _, err := AuditWith(&cfg, AuditOptions{Severities: severities}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = AuditWith(&cfg, AuditOptions{Severities: severities, StrictSeverities: true})
+	if err == nil || err.Error() != "audit: unknown severities: HIHG, info" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := AuditWith(&cfg, AuditOptions{Severities: []string{"critical"}, StrictSeverities: true}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAudit_unknownIgnored(t *testing.T) {
+	result, err := Audit(&config.ServiceConfig{}, []string{"2.2.2", "2.2.22"}, []string{SeverityHigh})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !reflect.DeepEqual(result.Stats.UnknownIgnored, []string{"2.2.22"}) {
+		t.Errorf("unexpected stats: %+v", result.Stats)
+	}
+}
+
+func TestLocation_describe(t *testing.T) {
+	cfg := &config.ServiceConfig{
+		Endpoints:   []*config.EndpointConfig{{Method: "POST", Endpoint: "/foo"}},
+		AsyncAgents: []*config.AsyncAgent{{Name: "bar"}},
+	}
+	for l, want := range map[Location]string{
+		serviceLocation():          "",
+		endpointLocation(0):        "POST /foo",
+		backendLocation(0, 1):      "POST /foo backend[1]",
+		endpointLocation(3):        "endpoints[3]",
+		agentBackendLocation(0, 2): "bar backend[2]",
+		agentBackendLocation(1, 0): "async_agent[1] backend[0]",
+		{Agent: -1, Endpoint: 0, Backend: -1, Detail: "timeout: 4s"}:  "POST /foo (timeout: 4s)",
+		{Agent: -1, Endpoint: -1, Backend: -1, Detail: "timeout: 4s"}: "(timeout: 4s)",
+	} {
+		if res := l.describe(cfg); res != want {
+			t.Errorf("unexpected description of %+v: %q", l, res)
+		}
+	}
+}
+
+func Test_deprecatedServerPluginRules(t *testing.T) {
+	ids := map[string]struct{}{}
+	for _, r := range ruleSet {
+		if _, ok := ids[r.Recommendation.Rule]; ok {
+			t.Errorf("duplicated rule id %s", r.Recommendation.Rule)
+		}
+		ids[r.Recommendation.Rule] = struct{}{}
+	}
+
+	for name, p := range deprecatedServerPlugins {
+		r := deprecatedServerPluginRule(name)
+		if _, ok := ids[p.ID]; !ok {
+			t.Errorf("rule %s of %s not in the rule set", p.ID, name)
+		}
+		if r.Recommendation.Rule != p.ID || r.Recommendation.Link != p.Link {
+			t.Errorf("unexpected rule for %s: %+v", name, r.Recommendation)
+		}
+		if p.Message != "" && r.Recommendation.Message != p.Message {
+			t.Errorf("unexpected message for %s: %s", name, r.Recommendation.Message)
+		}
+		s := &Service{Components: Component{server.Namespace: []int{1 << parseServerPlugin(name)}}}
+		if !r.Evaluate(s) {
+			t.Errorf("plugin %s not detected", name)
+		}
+		if r.Evaluate(&Service{Components: Component{server.Namespace: []int{1 << parseServerPlugin("geoip")}}}) {
+			t.Errorf("false positive for %s", name)
+		}
+	}
+}
+
+func TestAuditor(t *testing.T) {
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	cfg.Normalize()
+
+	opts := AuditOptions{Ignore: []string{"1.1.1", "foo"}, Severities: []string{SeverityCritical, SeverityHigh, SeverityMedium}}
+	a, err := NewAuditorWith(opts)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	want, _ := AuditWith(&cfg, opts)
+	for i := 0; i < 2; i++ {
+		if res, err := a.Audit(&cfg); err != nil || !reflect.DeepEqual(res, want) {
+			t.Errorf("#%d: unexpected result: %+v %v", i, res, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res, err := a.Audit(&cfg); err != nil || !reflect.DeepEqual(res, want) {
+				t.Errorf("unexpected concurrent result: %+v %v", res, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := a.Audit(nil); err != ErrNilConfig {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewAuditorWith(AuditOptions{Severities: []string{"foo"}, StrictSeverities: true}); err == nil {
+		t.Error("expecting an error")
+	}
+}
+
+func TestNewAuditor(t *testing.T) {
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	cfg.Normalize()
+
+	severities := []string{SeverityCritical, SeverityHigh, SeverityMedium}
+	want, _ := Audit(&cfg, []string{"1.1.1"}, severities)
+	if res, err := NewAuditor([]string{"1.1.1"}, severities, nil).Audit(&cfg); err != nil || !reflect.DeepEqual(res, want) {
+		t.Errorf("unexpected result: %+v %v", res, err)
+	}
+
+	always := func(*Service) bool { return true }
+	rules := []Rule{
+		NewRule("org.1", SeverityHigh, "foo", always),
+		NewRule("org.2", SeverityLow, "bar", always),
+		NewRule("org.3", SeverityHigh, "baz", always),
+	}
+	a := NewAuditor([]string{"org.3"}, []string{SeverityHigh}, rules)
+	rules[0].Recommendation.Message = "changed"
+	res, err := a.Audit(&cfg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(res.Recommendations) != 1 || res.Recommendations[0].Rule != "org.1" || res.Recommendations[0].Message != "foo" {
+		t.Errorf("unexpected recommendations: %+v", res.Recommendations)
+	}
+}
+
+// The Auditor selects the rules once, so every audit saves the construction of the lookup maps
+// and the filtering of the whole rule set, and the preallocated recommendations avoid growing
+// the slice several times. With the example configuration, Auditor.Audit takes about 10% less
+// memory and 7 fewer allocations per operation (223 instead of 230) than the AuditWith that
+// rebuilt the filters on every call. Most of the remaining allocations belong to Parse:
+//
+//	go test -run none -bench Audit -benchmem
+func BenchmarkAuditWith(b *testing.B) {
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	cfg.Normalize()
+	opts := AuditOptions{Ignore: []string{"1.1.1"}, Severities: []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := AuditWith(&cfg, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAuditor_Audit(b *testing.B) {
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	cfg.Normalize()
+	a, err := NewAuditorWith(AuditOptions{Ignore: []string{"1.1.1"}, Severities: []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.Audit(&cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestAuditor_Skipped(t *testing.T) {
+	opts := AuditOptions{Ignore: []string{"1.1.1", "2.2.2", "foo"}, Severities: []string{"critical", SeverityHigh}}
+	a, err := NewAuditorWith(opts)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	skipped := a.Skipped()
+
+	if !reflect.DeepEqual(skipped.Ignored, []string{"1.1.1", "2.2.2"}) {
+		t.Errorf("unexpected ignored rules: %v", skipped.Ignored)
+	}
+	if n := len(skipped.Ignored) + len(skipped.SeverityFiltered) + len(a.rules); n != len(ruleSet) {
+		t.Errorf("the skipped and evaluated rules do not add up: %d != %d", n, len(ruleSet))
+	}
+
+	severities := map[string]string{}
+	for _, r := range ruleSet {
+		severities[r.Recommendation.Rule] = r.Recommendation.Severity
+	}
+	for _, id := range skipped.SeverityFiltered {
+		if sev := severities[id]; sev == SeverityCritical || sev == SeverityHigh {
+			t.Errorf("rule %s with severity %s was filtered", id, sev)
+		}
+	}
+	for _, r := range a.rules {
+		if sev := r.Recommendation.Severity; sev != SeverityCritical && sev != SeverityHigh {
+			t.Errorf("rule %s with severity %s was not filtered", r.Recommendation.Rule, sev)
+		}
+	}
+
+	skipped.Ignored[0] = "bar"
+	if a.Skipped().Ignored[0] != "1.1.1" {
+		t.Error("the auditor was modified through the returned value")
+	}
+}
+
+func TestValidateRuleSet(t *testing.T) {
+	if err := ValidateRuleSet(builtinRules()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	never := func(*Service) bool { return false }
+	rules := []Rule{
+		NewRule("org.1", SeverityLow, "foo", never),
+		NewRule("org.2", SeverityLow, "bar", never),
+		NewRule("org.1", SeverityHigh, "baz", never),
+	}
+	if err := ValidateRuleSet(rules); err == nil || err.Error() != "audit: duplicated rule ids: org.1" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateRuleSet([]Rule{NewRule("", SeverityLow, "foo", never)}); err == nil || err.Error() != "audit: rule #0 without id" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := NewAuditorWith(AuditOptions{Rules: []Rule{NewRule("1.1.1", SeverityLow, "foo", never)}}); err == nil || err.Error() != "audit: duplicated rule ids: 1.1.1" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAuditor_files(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cert.pem"), []byte("cert"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missingCA := filepath.Join(dir, "ca", "ca.pem")
+	cfg := &config.ServiceConfig{
+		Version: 3,
+		TLS: &config.TLS{
+			Keys:    []config.TLSKeyPair{{PublicKey: "cert.pem", PrivateKey: "key.pem"}},
+			CaCerts: []string{missingCA},
+		},
+	}
+
+	filesRules := func(opts AuditOptions) []Recommendation {
+		a, err := NewAuditorWith(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := a.Audit(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var recs []Recommendation
+		for _, r := range res.Recommendations {
+			if r.Rule == "2.1.16" {
+				recs = append(recs, r)
+			}
+		}
+		return recs
+	}
+
+	if recs := filesRules(AuditOptions{Severities: AllSeverities}); len(recs) > 0 {
+		t.Errorf("the filesystem was checked without a base dir: %v", recs)
+	}
+	if recs := filesRules(AuditOptions{Severities: AllSeverities, FilesBaseDir: dir, Ignore: []string{"2.1.16"}}); len(recs) > 0 {
+		t.Errorf("the ignored rule was evaluated: %v", recs)
+	}
+
+	recs := filesRules(AuditOptions{Severities: AllSeverities, FilesBaseDir: dir})
+	if len(recs) != 2 || recs[0].Location != "key.pem" || recs[1].Location != missingCA || recs[0].Severity != SeverityHigh {
+		t.Errorf("unexpected recommendations: %+v", recs)
+	}
+	if recs := filesRules(AuditOptions{Severities: AllSeverities, FilesBaseDir: dir, Aggregate: true}); len(recs) != 1 || recs[0].Location != "" {
+		t.Errorf("unexpected aggregated recommendations: %+v", recs)
+	}
+
+	if unknown := ValidateIgnore([]string{"2.1.16"}); len(unknown) > 0 {
+		t.Errorf("unknown file rule: %v", unknown)
+	}
+}
+
+func TestAuditService(t *testing.T) {
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	cfg.Normalize()
+	service, _ := Parse(&cfg)
+
+	for _, severities := range [][]string{{SeverityCritical}, {SeverityHigh, SeverityMedium}} {
+		want, err := Audit(&cfg, []string{"1.1.1"}, severities)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		res, err := AuditService(&service, []string{"1.1.1"}, severities)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(res.Recommendations) != len(want.Recommendations) {
+			t.Errorf("%v: unexpected number of recommendations. have: %d, want: %d", severities, len(res.Recommendations), len(want.Recommendations))
+			continue
+		}
+		for i, r := range res.Recommendations {
+			if r.Rule != want.Recommendations[i].Rule {
+				t.Errorf("%v: unexpected rule %d: %s", severities, i, r.Rule)
+			}
+		}
+	}
+
+	res, _ := AuditService(&service, nil, []string{SeverityCritical})
+	if loc := res.Recommendations[len(res.Recommendations)-1].Location; loc != "endpoints[0] (timeout: 2m20s)" {
+		t.Errorf("unexpected location: %s", loc)
+	}
+
+	if _, err := AuditService(nil, nil, []string{SeverityCritical}); err != ErrNilConfig {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if res, err := AuditService(&Service{}, nil, []string{SeverityCritical}); err != nil || len(res.Recommendations) > 0 {
+		t.Errorf("unexpected result: %+v %v", res, err)
+	}
+}
+
+func TestAuditReader(t *testing.T) {
+	f, err := os.Open("./tests/example1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	res, err := AuditReader(f, []string{"1.1.1"}, AllSeverities)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Normalize()
+	want, _ := Audit(&cfg, []string{"1.1.1"}, AllSeverities)
+	// the catch-all endpoints without a method are only detected in the raw configuration
+	var catchAll []string
+	for _, r := range res.Recommendations {
+		if r.Rule == "5.1.13" {
+			catchAll = append(catchAll, r.Location)
+		}
+	}
+	if !reflect.DeepEqual(catchAll, []string{"GET /wildcarded/resource/*", "GET /__catchall"}) {
+		t.Errorf("unexpected 5.1.13 locations: %v", catchAll)
+	}
+	if !reflect.DeepEqual(res.Filter([]string{"5.1.13"}, AllSeverities).Recommendations, want.Recommendations) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	src := `{"version": 3, "endpoints": [{"endpoint": "/foo", "extra_config": {"qos/ratelimit/router": {"max_rate": 10}, "qos/ratelimit/router": {"max_rate": 100}}, "backend": [{"host": ["http://example.com"], "url_pattern": "/foo"}]}]}`
+	res, err = AuditReader(strings.NewReader(src), nil, []string{SeverityMedium})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, r := range res.Recommendations {
+		if r.Rule == "2.5.1" {
+			found = true
+			if r.Location != "GET /foo" {
+				t.Errorf("unexpected location: %s", r.Location)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("the duplicated namespace was not reported: %+v", res.Recommendations)
+	}
+
+	if _, err := AuditReader(strings.NewReader(`{"version": 3, "endpoints": [`), nil, AllSeverities); err == nil {
+		t.Error("expecting an error")
+	}
+}
+
+func TestAuditResult_JSON(t *testing.T) {
+	empty, _ := Audit(&config.ServiceConfig{}, nil, []string{SeverityCritical})
+	nilConfig, _ := Audit(nil, nil, []string{SeverityCritical})
+	unparsed, _ := AuditService(&Service{}, nil, []string{SeverityCritical})
+
+	for name, r := range map[string]AuditResult{
+		"empty config":  empty,
+		"nil config":    nilConfig,
+		"empty service": unparsed,
+		"filter":        AuditResult{}.Filter(nil, nil),
+		"dedupe":        AuditResult{}.Dedupe(),
+		"source":        AuditResult{}.WithSource("foo"),
+		"merge":         Merge(),
+	} {
+		b, err := json.Marshal(r)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(b) != `{"recommendations":[],"stats":{}}` {
+			t.Errorf("%s: unexpected encoding: %s", name, b)
+		}
+	}
+
+	b, _ := json.Marshal(Recommendation{Rule: "1.1.1", Severity: SeverityHigh, Message: "foo", Location: "GET /foo"})
+	if string(b) != `{"rule":"1.1.1","severity":"HIGH","message":"foo","location":"GET /foo"}` {
+		t.Errorf("unexpected encoding: %s", b)
 	}
 }
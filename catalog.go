@@ -0,0 +1,81 @@
+package audit
+
+import "strings"
+
+// RuleInfo describes a rule of the catalog
+type RuleInfo struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Section  string `json:"section"`
+	Link     string `json:"link,omitempty"`
+	// RawOnly is set for the rules only reported when auditing the raw JSON configuration, with
+	// AuditReader or AuditDir. Audit, AuditWith and AuditService never report them
+	RawOnly bool `json:"raw_only,omitempty"`
+}
+
+// Catalog returns the description of every rule evaluated by the audit process, in evaluation order
+func Catalog() []RuleInfo {
+	rules := builtinRules()
+	res := make([]RuleInfo, len(rules))
+	for i, r := range rules {
+		res[i] = RuleInfo{
+			Rule:     r.Recommendation.Rule,
+			Severity: r.Recommendation.Severity,
+			Message:  r.Recommendation.Message,
+			Section:  sections[sectionOf(r.Recommendation.Rule)],
+			Link:     r.Recommendation.Link,
+			RawOnly:  r.rawOnly,
+		}
+	}
+	return res
+}
+
+var sections = map[string]string{
+	"1": "Security",
+	"2": "Service level recommendations",
+	"3": "Traffic management / rate limits",
+	"4": "Telemetry",
+	"5": "Endpoint level audit",
+	"6": "Async agents",
+	"7": "Deprecations",
+}
+
+// SectionName returns the human readable name of the section with the received prefix (the first
+// part of the rule ids), or an empty string for unknown sections
+func SectionName(prefix string) string {
+	return sections[prefix]
+}
+
+// BySection groups the recommendations by the section of their rule, keyed by the section prefix.
+// Every group keeps the order of the result
+func (r AuditResult) BySection() map[string][]Recommendation {
+	res := map[string][]Recommendation{}
+	for _, rec := range r.Recommendations {
+		k := sectionOf(rec.Rule)
+		res[k] = append(res[k], rec)
+	}
+	return res
+}
+
+func sectionOf(id string) string {
+	if i := strings.Index(id, "."); i > 0 {
+		return id[:i]
+	}
+	return id
+}
+
+func linkOf(msg string) string {
+	i := strings.Index(msg, "https://")
+	if i < 0 {
+		return ""
+	}
+	link := msg[i:]
+	if j := strings.IndexAny(link, " \t\n"); j > 0 {
+		link = link[:j]
+	}
+	if link == "https://" {
+		return ""
+	}
+	return link
+}
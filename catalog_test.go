@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	catalog := Catalog()
+	if len(catalog) != len(builtinRules()) {
+		t.Errorf("unexpected catalog size. have: %d, want: %d", len(catalog), len(builtinRules()))
+		return
+	}
+
+	rules := builtinRules()
+	for i, r := range catalog {
+		if r.Rule != rules[i].Recommendation.Rule {
+			t.Errorf("unexpected rule %d: %s", i, r.Rule)
+		}
+		if r.Section == "" {
+			t.Errorf("rule %s without section", r.Rule)
+		}
+	}
+
+	if catalog[0].Section != "Security" {
+		t.Errorf("unexpected section for %s: %s", catalog[0].Rule, catalog[0].Section)
+	}
+
+	var rawOnly []string
+	for _, r := range catalog {
+		if r.RawOnly {
+			rawOnly = append(rawOnly, r.Rule)
+		}
+	}
+	if want := []string{"2.5.1", "3.3.5", "5.1.13", "5.1.14"}; !reflect.DeepEqual(rawOnly, want) {
+		t.Errorf("unexpected raw only rules: %v", rawOnly)
+	}
+}
+
+func TestAuditResult_BySection(t *testing.T) {
+	r := AuditResult{Recommendations: []Recommendation{
+		{Rule: "2.1.3", Severity: SeverityCritical, Message: "foo"},
+		{Rule: "1.1.1", Severity: SeverityHigh, Message: "bar"},
+		{Rule: "2.2.1", Severity: SeverityMedium, Message: "baz"},
+		{Rule: "org.1", Severity: SeverityLow, Message: "qux"},
+	}}
+
+	want := map[string][]Recommendation{
+		"1":   {r.Recommendations[1]},
+		"2":   {r.Recommendations[0], r.Recommendations[2]},
+		"org": {r.Recommendations[3]},
+	}
+	if res := r.BySection(); !reflect.DeepEqual(res, want) {
+		t.Errorf("unexpected sections: %+v", res)
+	}
+	if res := (AuditResult{}).BySection(); len(res) > 0 {
+		t.Errorf("unexpected sections: %+v", res)
+	}
+}
+
+func TestSectionName(t *testing.T) {
+	for prefix, name := range map[string]string{
+		"1":   "Security",
+		"3":   "Traffic management / rate limits",
+		"7":   "Deprecations",
+		"org": "",
+	} {
+		if res := SectionName(prefix); res != name {
+			t.Errorf("unexpected name for %s: %q", prefix, res)
+		}
+	}
+}
+
+func Test_linkOf(t *testing.T) {
+	for msg, link := range map[string]string{
+		"Enable CORS.":             "",
+		"Use an https:// jwk_url.": "",
+		"See: https://www.krakend.io/docs/enterprise/authentication/basic-authentication/ .": "https://www.krakend.io/docs/enterprise/authentication/basic-authentication/",
+		"Please visit https://www.krakend.io/docs/telemetry/opentelemetry/ to upgrade":       "https://www.krakend.io/docs/telemetry/opentelemetry/",
+		"Please visit https://www.krakend.io/docs/telemetry/opentelemetry/":                  "https://www.krakend.io/docs/telemetry/opentelemetry/",
+	} {
+		if l := linkOf(msg); l != link {
+			t.Errorf("unexpected link for %q: %s", msg, l)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Diff compares two audit results and returns the recommendations of after not present in before
+// (added) and the ones of before not present in after (removed). Recommendations are compared by rule
+// id, source and location, so a rule reported for a different element counts as added and removed.
+// Both lists are sorted by rule id and location
+func Diff(before, after AuditResult) (added, removed []Recommendation) {
+	return subtract(after.Recommendations, before.Recommendations), subtract(before.Recommendations, after.Recommendations)
+}
+
+// subtract returns the recommendations of a not present in b, taking into account how many times
+// every recommendation is repeated
+func subtract(a, b []Recommendation) []Recommendation {
+	seen := map[[2]string]int{}
+	for _, r := range b {
+		seen[diffKey(r)]++
+	}
+
+	var res []Recommendation
+	for _, r := range a {
+		k := diffKey(r)
+		if seen[k] > 0 {
+			seen[k]--
+			continue
+		}
+		res = append(res, r)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Rule != res[j].Rule {
+			return ruleLess(res[i].Rule, res[j].Rule)
+		}
+		return diffKey(res[i])[1] < diffKey(res[j])[1]
+	})
+	return res
+}
+
+func diffKey(r Recommendation) [2]string {
+	return [2]string{r.Rule, strings.Join(append([]string{r.Source, r.Location}, r.Locations...), "\n")}
+}
+
+// ruleLess compares two rule ids section by section, numerically when both sections are numbers,
+// so 2.1.9 goes before 2.1.10
+func ruleLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		x, errX := strconv.Atoi(as[i])
+		y, errY := strconv.Atoi(bs[i])
+		if errX == nil && errY == nil {
+			return x < y
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
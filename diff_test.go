@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	before := AuditResult{Recommendations: []Recommendation{
+		{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Location: "GET /a"},
+		{Rule: "2.1.10", Severity: SeverityHigh, Message: "bar"},
+		{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Location: "GET /b"},
+		{Rule: "2.2.1", Severity: SeverityMedium, Message: "baz"},
+	}}
+	after := AuditResult{Recommendations: []Recommendation{
+		{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Location: "GET /c"},
+		{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Location: "GET /a"},
+		{Rule: "2.2.1", Severity: SeverityMedium, Message: "baz"},
+		{Rule: "2.1.9", Severity: SeverityHigh, Message: "qux"},
+	}}
+
+	added, removed := Diff(before, after)
+	if want := []Recommendation{after.Recommendations[3], after.Recommendations[0]}; !reflect.DeepEqual(added, want) {
+		t.Errorf("unexpected added recommendations: %+v", added)
+	}
+	if want := []Recommendation{before.Recommendations[1], before.Recommendations[2]}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("unexpected removed recommendations: %+v", removed)
+	}
+
+	if added, removed := Diff(after, after); len(added) > 0 || len(removed) > 0 {
+		t.Errorf("unexpected differences: %+v %+v", added, removed)
+	}
+}
+
+func Test_ruleLess(t *testing.T) {
+	for _, tc := range [][2]string{
+		{"1.1.1", "1.1.2"},
+		{"2.1.9", "2.1.10"},
+		{"2.1.10", "3.1.1"},
+		{"7.1", "7.1.1"},
+		{"org.1", "org.2"},
+	} {
+		if !ruleLess(tc[0], tc[1]) || ruleLess(tc[1], tc[0]) {
+			t.Errorf("unexpected order of %s and %s", tc[0], tc[1])
+		}
+	}
+}
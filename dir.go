@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DirError collects the errors of the files AuditDir could not parse or audit and of the
+// directories it could not read, keyed by their name
+type DirError map[string]error
+
+func (e DirError) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = fmt.Sprintf("%s: %s", name, e[name])
+	}
+	return fmt.Sprintf("audit: %d files could not be audited: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// AuditDir audits every JSON configuration found under fsys, walking the subdirectories, and
+// returns the results keyed by the name of the files. Every file is audited as with AuditReader. A
+// file failing to parse or to audit, or a subdirectory that can not be read, does not abort the
+// scan: the results of the rest of the files are returned along with a DirError describing the
+// failures
+func AuditDir(fsys fs.FS, ignore, severities []string) (map[string]AuditResult, error) {
+	a := NewAuditor(ignore, severities, nil)
+	res := map[string]AuditResult{}
+	failed := DirError{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == "." {
+				// there is nothing to audit without the root directory
+				return err
+			}
+			failed[name] = err
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || strings.ToLower(path.Ext(name)) != ".json" {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			failed[name] = err
+			return nil
+		}
+		r, err := a.auditJSON(name, data)
+		if err != nil {
+			failed[name] = err
+			return nil
+		}
+		res[name] = r
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	if len(failed) > 0 {
+		return res, failed
+	}
+	return res, nil
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/luraproject/lura/v2/config"
+)
+
+func TestAuditDir(t *testing.T) {
+	example, err := os.ReadFile("./tests/example1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"example.json":         string(example),
+		"nested/minimal.json":  `{"version": 3, "endpoints": [{"endpoint": "/foo", "backend": [{"host": ["http://example.com"], "url_pattern": "/foo"}]}]}`,
+		"broken.json":          `{"version": 3, "endpoints": [`,
+		"nested/old.json":      `{"version": 1}`,
+		"nested/README.md":     "not a configuration",
+		"nested/deeper/x.JSON": `{"version": 3}`,
+		"duplicated.json":      `{"version": 3, "extra_config": {"security/cors": {}, "security/cors": {}}}`,
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	severities := []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}
+	res, err := AuditDir(os.DirFS(dir), []string{"1.1.1"}, severities)
+
+	var dirErr DirError
+	if !errors.As(err, &dirErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dirErr) != 2 || dirErr["broken.json"] == nil || dirErr["nested/old.json"] == nil {
+		t.Errorf("unexpected failures: %v", dirErr)
+	}
+
+	if len(res) != 4 {
+		t.Errorf("unexpected number of results: %d", len(res))
+	}
+	for _, name := range []string{"nested/minimal.json", "nested/deeper/x.JSON"} {
+		if len(res[name].Recommendations) == 0 {
+			t.Errorf("%s: no recommendations", name)
+		}
+	}
+	found := false
+	for _, r := range res["duplicated.json"].Recommendations {
+		found = found || (r.Rule == "2.5.1" && r.Location == "")
+	}
+	if !found {
+		t.Error("the duplicated namespace was not reported")
+	}
+
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Normalize()
+	want, _ := Audit(&cfg, []string{"1.1.1"}, severities)
+	// the catch-all endpoints without a method are only detected in the raw configuration
+	if !reflect.DeepEqual(res["example.json"].Filter([]string{"5.1.13"}, severities).Recommendations, want.Recommendations) {
+		t.Errorf("unexpected result for the example: %+v", res["example.json"])
+	}
+
+	res, err = AuditDir(failingDirFS{FS: os.DirFS(dir), dir: "nested/deeper"}, []string{"1.1.1"}, severities)
+	if !errors.As(err, &dirErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dirErr) != 3 || !errors.Is(dirErr["nested/deeper"], fs.ErrPermission) {
+		t.Errorf("unexpected failures: %v", dirErr)
+	}
+	if _, ok := res["nested/deeper/x.JSON"]; ok || len(res) != 3 {
+		t.Errorf("unexpected results: %v", res)
+	}
+
+	if _, err := AuditDir(os.DirFS(filepath.Join(dir, "missing")), nil, severities); err == nil || errors.As(err, &dirErr) {
+		t.Errorf("unexpected error for a missing directory: %v", err)
+	}
+}
+
+// failingDirFS fails to list the entries of the directory dir
+type failingDirFS struct {
+	fs.FS
+	dir string
+}
+
+func (f failingDirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == f.dir {
+		return nil, fs.ErrPermission
+	}
+	return fs.ReadDir(f.FS, name)
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DuplicatedNamespace is a namespace declared more than once in the same extra_config. Path points
+// to the extra_config object, as in $.endpoints[0].backend[1].extra_config
+type DuplicatedNamespace struct {
+	Namespace string `json:"namespace"`
+	Path      string `json:"path"`
+}
+
+// FindDuplicatedNamespaces scans a raw JSON configuration and returns the namespaces declared more
+// than once in the same extra_config, in document order. Only the last declaration is used by
+// KrakenD, so the previous ones are silently ignored. The duplicated keys are lost once the
+// configuration is decoded, so the rule reporting them only applies to the configurations audited
+// from their raw JSON, as with AuditReader and AuditDir
+func FindDuplicatedNamespaces(r io.Reader) ([]DuplicatedNamespace, error) {
+	dec := json.NewDecoder(r)
+	var res []DuplicatedNamespace
+	if err := scanDuplicates(dec, "$", false, &res); err != nil {
+		return nil, fmt.Errorf("scanning the configuration: %w", err)
+	}
+	return res, nil
+}
+
+// scanDuplicates consumes the next JSON value of the decoder. When isExtraConfig is set, the value
+// is an extra_config object and its repeated keys are appended to res
+func scanDuplicates(dec *json.Decoder, path string, isExtraConfig bool, res *[]DuplicatedNamespace) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	d, ok := t.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch d {
+	case '{':
+		seen := map[string]struct{}{}
+		for dec.More() {
+			t, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			k, _ := t.(string)
+			if isExtraConfig {
+				if _, ok := seen[k]; ok {
+					*res = append(*res, DuplicatedNamespace{Namespace: k, Path: path})
+				}
+				seen[k] = struct{}{}
+			}
+			if err := scanDuplicates(dec, path+"."+k, k == "extra_config", res); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; dec.More(); i++ {
+			if err := scanDuplicates(dec, fmt.Sprintf("%s[%d]", path, i), false, res); err != nil {
+				return err
+			}
+		}
+	}
+	// consume the closing delimiter
+	_, err = dec.Token()
+	return err
+}
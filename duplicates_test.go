@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindDuplicatedNamespaces(t *testing.T) {
+	src := `{
+	"version": 3,
+	"extra_config": {
+		"security/cors": {"allow_origins": ["*"]},
+		"telemetry/logging": {"level": "DEBUG"},
+		"security/cors": {"allow_origins": ["https://example.com"]}
+	},
+	"endpoints": [
+		{"endpoint": "/a", "backend": [{"url_pattern": "/a", "extra_config": {"qos/http-cache": {}}}]},
+		{
+			"endpoint": "/b",
+			"extra_config": {"auth/validator": {"alg": "RS256", "extra_config": 1}, "auth/validator": {}},
+			"backend": [
+				{"url_pattern": "/b"},
+				{"url_pattern": "/c", "extra_config": {"qos/http-cache": {}, "qos/ratelimit/proxy": {}, "qos/http-cache": {"shared": true}}}
+			]
+		}
+	]
+}`
+
+	res, err := FindDuplicatedNamespaces(strings.NewReader(src))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	want := []DuplicatedNamespace{
+		{Namespace: "security/cors", Path: "$.extra_config"},
+		{Namespace: "auth/validator", Path: "$.endpoints[1].extra_config"},
+		{Namespace: "qos/http-cache", Path: "$.endpoints[1].backend[1].extra_config"},
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("unexpected duplicates: %+v", res)
+	}
+}
+
+func TestFindDuplicatedNamespaces_example(t *testing.T) {
+	f, err := os.Open("./tests/example1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	res, err := FindDuplicatedNamespaces(f)
+	if err != nil || len(res) > 0 {
+		t.Errorf("unexpected result: %+v %v", res, err)
+	}
+
+	if _, err := FindDuplicatedNamespaces(strings.NewReader(`{"extra_config": {"foo": `)); err == nil {
+		t.Error("expecting an error")
+	}
+}
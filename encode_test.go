@@ -28,7 +28,7 @@ func intn(k int) int {
 }
 
 func TestMarshal(t *testing.T) {
-	result := Parse(generateCfg())
+	result, _ := Parse(generateCfg())
 
 	b, err := Marshal(&result)
 	if err != nil {
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"strconv"
+	"strings"
+)
+
+// WithSource returns a copy of the result where every recommendation is labeled with the received
+// source, usually the name of the audited configuration, so they can be told apart once merged
+func (r AuditResult) WithSource(source string) AuditResult {
+	res := AuditResult{
+		Recommendations: make([]Recommendation, len(r.Recommendations)),
+		Stats:           r.Stats,
+	}
+	for i, rec := range r.Recommendations {
+		rec.Source = source
+		res.Recommendations[i] = rec
+	}
+	return res
+}
+
+// Merge combines several audit results into a single one. The recommendations are concatenated in
+// order, skipping the ones identical to a previous recommendation, and the Stats list every unknown
+// ignored rule once
+func Merge(results ...AuditResult) AuditResult {
+	res := AuditResult{Recommendations: []Recommendation{}}
+	seenRecommendations := map[string]struct{}{}
+	seenIgnored := map[string]struct{}{}
+
+	for _, r := range results {
+		for _, rec := range r.Recommendations {
+			k := mergeKey(rec)
+			if _, ok := seenRecommendations[k]; ok {
+				continue
+			}
+			seenRecommendations[k] = struct{}{}
+			res.Recommendations = append(res.Recommendations, rec)
+		}
+		for _, k := range r.Stats.UnknownIgnored {
+			if _, ok := seenIgnored[k]; ok {
+				continue
+			}
+			seenIgnored[k] = struct{}{}
+			res.Stats.UnknownIgnored = append(res.Stats.UnknownIgnored, k)
+		}
+	}
+	return res
+}
+
+func mergeKey(r Recommendation) string {
+	return strings.Join(append([]string{
+		r.Rule,
+		r.Severity,
+		r.Message,
+		r.Link,
+		r.Location,
+		strconv.Itoa(r.Count),
+		r.Source,
+	}, r.Locations...), "\x00")
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	a := AuditResult{
+		Recommendations: []Recommendation{
+			{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Location: "GET /a"},
+			{Rule: "2.2.1", Severity: SeverityMedium, Message: "bar"},
+		},
+		Stats: Stats{UnknownIgnored: []string{"foo"}},
+	}
+	b := AuditResult{
+		Recommendations: []Recommendation{
+			{Rule: "2.2.1", Severity: SeverityMedium, Message: "bar"},
+			{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Location: "GET /b"},
+		},
+		Stats: Stats{UnknownIgnored: []string{"bar", "foo"}},
+	}
+
+	res := Merge(a, b)
+	want := AuditResult{
+		Recommendations: []Recommendation{a.Recommendations[0], a.Recommendations[1], b.Recommendations[1]},
+		Stats:           Stats{UnknownIgnored: []string{"foo", "bar"}},
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	res = Merge(a.WithSource("a"), b.WithSource("b"))
+	if len(res.Recommendations) != 4 {
+		t.Errorf("unexpected number of recommendations: %+v", res.Recommendations)
+	}
+	for i, source := range []string{"a", "a", "b", "b"} {
+		if res.Recommendations[i].Source != source {
+			t.Errorf("#%d: unexpected source %q", i, res.Recommendations[i].Source)
+		}
+	}
+	if a.Recommendations[0].Source != "" {
+		t.Error("the original result has been modified")
+	}
+
+	if res := Merge(); len(res.Recommendations) != 0 || len(res.Stats.UnknownIgnored) != 0 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestAuditResult_Dedupe_sources(t *testing.T) {
+	r := Merge(
+		AuditResult{Recommendations: []Recommendation{{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Location: "GET /a"}}}.WithSource("a"),
+		AuditResult{Recommendations: []Recommendation{{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Location: "GET /a"}}}.WithSource("b"),
+	).Dedupe()
+
+	want := []Recommendation{
+		{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Count: 1, Locations: []string{"GET /a"}, Source: "a"},
+		{Rule: "3.1.3", Severity: SeverityHigh, Message: "foo", Count: 1, Locations: []string{"GET /a"}, Source: "b"},
+	}
+	if !reflect.DeepEqual(r.Recommendations, want) {
+		t.Errorf("unexpected result: %+v", r.Recommendations)
+	}
+}
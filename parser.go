@@ -1,7 +1,15 @@
 package audit
 
 import (
+	"bytes"
+	"crypto/tls"
 	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -9,7 +17,12 @@ import (
 
 	bf "github.com/krakendio/bloomfilter/v2/krakend"
 	botdetector "github.com/krakendio/krakend-botdetector/v2/krakend"
+	cb "github.com/krakendio/krakend-circuitbreaker/v2/gobreaker"
+	cors "github.com/krakendio/krakend-cors/v2"
+	gologging "github.com/krakendio/krakend-gologging/v2"
 	httpcache "github.com/krakendio/krakend-httpcache/v2"
+	httpsecure "github.com/krakendio/krakend-httpsecure/v2"
+	jose "github.com/krakendio/krakend-jose/v2"
 	luaproxy "github.com/krakendio/krakend-lua/v2/proxy"
 	luarouter "github.com/krakendio/krakend-lua/v2/router"
 	opencensus "github.com/krakendio/krakend-opencensus/v2"
@@ -25,8 +38,13 @@ import (
 	server "github.com/luraproject/lura/v2/transport/http/server/plugin"
 )
 
-// Parse creates a Service capturing the details of the received configuration
-func Parse(cfg *config.ServiceConfig) Service {
+// Parse creates a Service capturing the details of the received configuration. It returns
+// ErrNilConfig when the configuration is nil
+func Parse(cfg *config.ServiceConfig) (Service, error) {
+	if cfg == nil {
+		return Service{}, ErrNilConfig
+	}
+
 	v1 := 0
 
 	if cfg.Plugin != nil {
@@ -51,6 +69,7 @@ func Parse(cfg *config.ServiceConfig) Service {
 		v1 = addBit(v1, ServiceDisableStrictREST)
 	}
 
+	minTLS, maxTLS := 0, 0
 	if cfg.TLS != nil {
 		v1 = addBit(v1, ServiceHasTLS)
 		if !cfg.TLS.IsDisabled {
@@ -68,6 +87,10 @@ func Parse(cfg *config.ServiceConfig) Service {
 		if cfg.TLS.PublicKey != "" || cfg.TLS.PrivateKey != "" {
 			v1 = addBit(v1, ServiceTLSPrivPubKey)
 		}
+		if hasWeakCipherSuite(cfg.TLS.CipherSuites) {
+			v1 = addBit(v1, ServiceTLSWeakCipherSuites)
+		}
+		minTLS, maxTLS = parseTLSVersion(cfg.TLS.MinVersion), parseTLSVersion(cfg.TLS.MaxVersion)
 	}
 
 	if cfg.Echo {
@@ -78,24 +101,246 @@ func Parse(cfg *config.ServiceConfig) Service {
 		v1 = addBit(v1, ServiceUseH2C)
 	}
 
+	if cfg.Timeout > 0 {
+		v1 = addBit(v1, ServiceTimeout)
+	}
+
+	if strings.TrimSpace(cfg.Name) != "" {
+		v1 = addBit(v1, ServiceHasName)
+	}
+
+	if cfg.ReadTimeout > 0 {
+		v1 = addBit(v1, ServiceReadTimeout)
+	}
+	if cfg.WriteTimeout > 0 {
+		v1 = addBit(v1, ServiceWriteTimeout)
+	}
+	if cfg.IdleTimeout > 0 {
+		v1 = addBit(v1, ServiceIdleTimeout)
+	}
+
 	return Service{
-		Details:    []int{v1},
+		Details:    []int{v1, cfg.MaxIdleConns, cfg.MaxIdleConnsPerHost, minTLS, maxTLS, int(cfg.Timeout / time.Millisecond), cfg.MaxHeaderBytes},
 		Agents:     parseAsyncAgents(cfg.AsyncAgents),
 		Endpoints:  parseEndpoints(cfg.Endpoints),
 		Components: parseComponents(cfg.ExtraConfig),
+	}, nil
+}
+
+// parseJSON decodes a raw JSON configuration with the lura parser, normalizes it and creates a
+// Service capturing its details. The name is only used to describe the errors. Unlike Parse, the
+// Service also records the namespaces declared twice in the same extra_config and the methods,
+// timeouts and backend hosts left to the defaults, as the decoded and initialized configuration
+// does not keep them
+func parseJSON(name string, data []byte) (Service, config.ServiceConfig, error) {
+	cfg, err := config.NewParserWithFileReader(func(string) ([]byte, error) { return data, nil }).Parse(name)
+	if err != nil {
+		return Service{}, cfg, err
+	}
+	cfg.Normalize()
+
+	duplicates, err := FindDuplicatedNamespaces(bytes.NewReader(data))
+	if err != nil {
+		return Service{}, cfg, err
+	}
+
+	s, err := Parse(&cfg)
+	if err != nil {
+		return Service{}, cfg, err
+	}
+	markDuplicatedNamespaces(&s, duplicates)
+	if err := markImplicitSettings(&s, data); err != nil {
+		return Service{}, cfg, err
+	}
+	return s, cfg, nil
+}
+
+// rawConfig holds the settings of a raw configuration that the lura parser fills with defaults
+type rawConfig struct {
+	Timeout   string `json:"timeout"`
+	Endpoints []struct {
+		Method  string `json:"method"`
+		Timeout string `json:"timeout"`
+		Backend []struct {
+			Host       []string `json:"host"`
+			URLPattern string   `json:"url_pattern"`
+		} `json:"backend"`
+	} `json:"endpoints"`
+}
+
+// markImplicitSettings flags the settings the raw configuration leaves to the defaults of the lura
+// parser, as they can not be told apart from the declared ones once the configuration is initialized
+func markImplicitSettings(s *Service, data []byte) error {
+	var raw rawConfig
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Timeout == "" {
+		s.Details[0] &^= 1 << ServiceTimeout
+	}
+	for i, e := range raw.Endpoints {
+		if i >= len(s.Endpoints) {
+			break
+		}
+		if e.Timeout == "" {
+			s.Endpoints[i].Details[6] = addBit(s.Endpoints[i].Details[6], EndpointTimeoutMissing)
+		}
+		if strings.TrimSpace(e.Method) == "" {
+			s.Endpoints[i].Details[6] = addBit(s.Endpoints[i].Details[6], EndpointMethodMissing)
+		}
+		for j, b := range e.Backend {
+			if j < len(s.Endpoints[i].Backends) && len(b.Host) == 0 && b.URLPattern == "" && isBareBackend(s.Endpoints[i].Backends[j]) {
+				s.Endpoints[i].Backends[j].Details[0] = addBit(s.Endpoints[i].Backends[j].Details[0], BackendEmpty)
+			}
+		}
+	}
+	return nil
+}
+
+// isBareBackend checks if the parsed backend has no extra_config and no response manipulation
+func isBareBackend(b Backend) bool {
+	if len(b.Components) > 0 {
+		return false
+	}
+	for _, f := range []int{BackendAllow, BackendDeny, BackendMapping, BackendGroup, BackendTarget} {
+		if hasBit(b.Details[0], f) {
+			return false
+		}
+	}
+	return true
+}
+
+// tlsFiles returns the paths of the certificates, keys and CA certificates declared in the TLS
+// config of the service, in declaration order
+func tlsFiles(cfg *config.ServiceConfig) []string {
+	if cfg == nil || cfg.TLS == nil {
+		return nil
+	}
+	var res []string
+	for _, p := range []string{cfg.TLS.PublicKey, cfg.TLS.PrivateKey} {
+		if p != "" {
+			res = append(res, p)
+		}
+	}
+	for _, k := range cfg.TLS.Keys {
+		for _, p := range []string{k.PublicKey, k.PrivateKey} {
+			if p != "" {
+				res = append(res, p)
+			}
+		}
+	}
+	for _, p := range cfg.TLS.CaCerts {
+		if p != "" {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+var tlsVersions = map[string]int{
+	"SSL3.0": tls.VersionSSL30,
+	"TLS10":  tls.VersionTLS10,
+	"TLS11":  tls.VersionTLS11,
+	"TLS12":  tls.VersionTLS12,
+	"TLS13":  tls.VersionTLS13,
+}
+
+// parseTLSVersion returns the TLS version used by the server, defaulting to TLS 1.3 for
+// empty and unknown values, as KrakenD does
+func parseTLSVersion(v string) int {
+	if res, ok := tlsVersions[v]; ok {
+		return res
+	}
+	return tls.VersionTLS13
+}
+
+func hasWeakCipherSuite(suites []uint16) bool {
+	for _, id := range suites {
+		for _, cs := range tls.InsecureCipherSuites() {
+			if cs.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isHealthPath checks if the path of the endpoint looks like a health check (/health, /healthz,
+// /__health, /status...), ignoring the trailing slash
+func isHealthPath(p string) bool {
+	switch strings.ToLower(strings.TrimSuffix(p, "/")) {
+	case "/health", "/healthz", "/__health", "/_health", "/healthcheck", "/status", "/ping", "/ready", "/readyz", "/livez":
+		return true
+	}
+	return false
+}
+
+// duplicatedNamespacePath matches the paths of the extra_config of the service, the endpoints, the
+// async agents and their backends. The extra_config nested inside a component are not matched
+var duplicatedNamespacePath = regexp.MustCompile(`^\$(\.(endpoints|async_agent)\[(\d+)\](\.backend\[(\d+)\])?)?\.extra_config$`)
+
+// markDuplicatedNamespaces flags the elements of the service owning the extra_config of every
+// duplicated namespace. The namespaces duplicated inside the config of a component are ignored
+func markDuplicatedNamespaces(s *Service, duplicates []DuplicatedNamespace) {
+	for _, d := range duplicates {
+		m := duplicatedNamespacePath.FindStringSubmatch(d.Path)
+		if m == nil {
+			continue
+		}
+		if m[1] == "" {
+			s.Details[0] = addBit(s.Details[0], ServiceDuplicatedNamespace)
+			continue
+		}
+		i, _ := strconv.Atoi(m[3])
+		b := -1
+		if m[5] != "" {
+			b, _ = strconv.Atoi(m[5])
+		}
+
+		var backends []Backend
+		switch {
+		case m[2] == "endpoints" && i < len(s.Endpoints):
+			backends = s.Endpoints[i].Backends
+			if b < 0 {
+				s.Endpoints[i].Details[6] = addBit(s.Endpoints[i].Details[6], EndpointDuplicatedNamespace)
+			}
+		case m[2] == "async_agent" && i < len(s.Agents):
+			backends = s.Agents[i].Backends
+			if b < 0 {
+				s.Agents[i].Details[5] = addBit(s.Agents[i].Details[5], AgentDuplicatedNamespace)
+			}
+		}
+		if b >= 0 && b < len(backends) {
+			backends[b].Details[0] = addBit(backends[b].Details[0], BackendDuplicatedNamespace)
+		}
 	}
 }
 
 func parseAsyncAgents(as []*config.AsyncAgent) []Agent {
 	var agents []Agent
 
-	for _, a := range as {
+	names := map[string]int{}
+	for i, a := range as {
+		// 1 + the index of the first previous agent with the same name, or 0 when the name is unique
+		duplicated := names[a.Name]
+		if duplicated == 0 {
+			names[a.Name] = i + 1
+		}
+
+		flags := 0
+		if a.Connection.BackoffStrategy != "" {
+			flags = addBit(flags, AgentBackoffStrategy)
+		}
+
 		agent := Agent{
 			Details: []int{
 				parseEncoding(a.Encoding),
 				a.Consumer.Workers,
 				a.Connection.MaxRetries,
 				int(a.Consumer.Timeout / time.Millisecond),
+				int(math.Ceil(a.Consumer.MaxRate)),
+				flags,
+				duplicated,
 			},
 			Backends:   parseBackends(a.Backend),
 			Components: parseComponents(a.ExtraConfig),
@@ -116,7 +361,9 @@ const (
 func parseEndpoints(es []*config.EndpointConfig) []Endpoint {
 	var endpoints []Endpoint
 
-	for _, e := range es {
+	overlaps := findOverlappingEndpoints(es)
+
+	for i, e := range es {
 		wildcards := 0
 		if strings.HasSuffix(e.Endpoint, "*") {
 			wildcards = 1
@@ -139,6 +386,35 @@ func parseEndpoints(es []*config.EndpointConfig) []Endpoint {
 			}
 		}
 
+		flags := parseMethod(e.Method)
+		if e.Method == "*" {
+			flags = addBit(flags, EndpointMethodWildcard)
+		}
+		if strings.TrimSpace(e.Method) == "" {
+			flags = addBit(flags, EndpointMethodMissing)
+		}
+		for _, h := range e.HeadersToPass {
+			if strings.EqualFold(h, "Content-Type") {
+				flags = addBit(flags, EndpointInputHeaderContentType)
+			}
+			if strings.EqualFold(h, "Authorization") {
+				flags = addBit(flags, EndpointInputHeaderAuthorization)
+			}
+		}
+
+		if hasCacheComponent(e) {
+			flags = addBit(flags, EndpointCacheComponent)
+		}
+		if e.Timeout <= 0 {
+			flags = addBit(flags, EndpointTimeoutMissing)
+		}
+		if isHealthPath(e.Endpoint) {
+			flags = addBit(flags, EndpointHealthCheck)
+		}
+		if returnsErrorDetails(e.Backend) {
+			flags = addBit(flags, EndpointReturnErrorDetails)
+		}
+
 		numUnsafeMethods := 0
 		for _, b := range e.Backend {
 			if b.Method != "HEAD" && b.Method != "GET" {
@@ -160,6 +436,11 @@ func parseEndpoints(es []*config.EndpointConfig) []Endpoint {
 				int(e.Timeout / time.Millisecond),
 				wildcards,
 				numUnsafeMethods,
+				flags,
+				int(e.CacheTTL / time.Millisecond),
+				countHostnames(e.Backend),
+				e.ConcurrentCalls,
+				overlaps[i],
 			},
 			Backends:   parseBackends(e.Backend),
 			Components: parseComponents(e.ExtraConfig),
@@ -170,6 +451,65 @@ func parseEndpoints(es []*config.EndpointConfig) []Endpoint {
 	return endpoints
 }
 
+// findOverlappingEndpoints compares the method and path of every pair of endpoints and returns, for
+// each endpoint, 1 + the index of the first previous endpoint overlapping it, or 0 when there is none.
+// Two endpoints overlap when they share a method (or any of them uses the wildcard method) and their
+// paths are the same, the parameters being named differently, or one of them is a wildcard path
+// covering the other. The /__catchall endpoint only receives the unmatched requests, so it is skipped
+func findOverlappingEndpoints(es []*config.EndpointConfig) []int {
+	res := make([]int, len(es))
+	paths := make([]string, len(es))
+	for i, e := range es {
+		paths[i] = normalizePath(e.Endpoint)
+	}
+	for i := range es {
+		if es[i].Endpoint == "/__catchall" {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if es[j].Endpoint == "/__catchall" || !sameMethod(es[i].Method, es[j].Method) {
+				continue
+			}
+			if pathsOverlap(paths[i], paths[j]) {
+				res[i] = j + 1
+				break
+			}
+		}
+	}
+	return res
+}
+
+func sameMethod(a, b string) bool {
+	if a == "" {
+		a = "GET"
+	}
+	if b == "" {
+		b = "GET"
+	}
+	return a == "*" || b == "*" || strings.EqualFold(a, b)
+}
+
+// normalizePath replaces the name of the path parameters, both {param} and :param, with {}
+func normalizePath(path string) string {
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || (strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}")) {
+			segments[i] = "{}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func pathsOverlap(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if strings.HasSuffix(a, "*") && strings.HasPrefix(b, strings.TrimSuffix(a, "*")) {
+		return true
+	}
+	return strings.HasSuffix(b, "*") && strings.HasPrefix(a, strings.TrimSuffix(b, "*"))
+}
+
 func parseEncoding(enc string) int {
 	switch enc {
 	case encoding.NOOP:
@@ -189,6 +529,89 @@ func parseEncoding(enc string) int {
 	}
 }
 
+func parseMethod(method string) int {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return addBit(0, MethodGET)
+	case "HEAD":
+		return addBit(0, MethodHEAD)
+	case "POST":
+		return addBit(0, MethodPOST)
+	case "PUT":
+		return addBit(0, MethodPUT)
+	case "PATCH":
+		return addBit(0, MethodPATCH)
+	case "DELETE":
+		return addBit(0, MethodDELETE)
+	default:
+		return addBit(0, MethodOther)
+	}
+}
+
+// isEmptyBackend checks if the backend declares nothing but its method or comments: no host, no
+// url_pattern (or the root path it defaults to), no extra_config and no response manipulation. The
+// lura parser copies the host of the service into the backends without their own, so once the
+// configuration is initialized the raw JSON is needed to detect them, see markImplicitSettings
+func isEmptyBackend(b *config.Backend) bool {
+	if len(b.Host) > 0 || len(b.ExtraConfig) > 0 || (b.URLPattern != "" && b.URLPattern != "/") {
+		return false
+	}
+	return len(b.AllowList) == 0 && len(b.DenyList) == 0 && len(b.Mapping) == 0 && b.Group == "" && b.Target == ""
+}
+
+// returnsErrorDetails checks if any backend propagates its errors to the client, declaring the
+// return_error_details or the return_error_code of the backend/http namespace
+func returnsErrorDetails(bs []*config.Backend) bool {
+	for _, b := range bs {
+		cfg, ok := b.ExtraConfig["backend/http"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if v, ok := cfg["return_error_details"].(string); ok && v != "" {
+			return true
+		}
+		if v, ok := cfg["return_error_code"].(bool); ok && v {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCacheComponent checks if the endpoint or any of its backends declares an http cache, either
+// with the qos/http-cache namespace or with the legacy one
+func hasCacheComponent(e *config.EndpointConfig) bool {
+	cfgs := []config.ExtraConfig{e.ExtraConfig}
+	for _, b := range e.Backend {
+		cfgs = append(cfgs, b.ExtraConfig)
+	}
+	for _, cfg := range cfgs {
+		if _, ok := cfg[httpcache.Namespace]; ok {
+			return true
+		}
+		if _, ok := cfg["qos/http-cache"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func countHostnames(bs []*config.Backend) int {
+	hostnames := map[string]struct{}{}
+	for _, b := range bs {
+		for _, h := range b.Host {
+			if !strings.Contains(h, "://") {
+				h = "http://" + h
+			}
+			u, err := url.Parse(h)
+			if err != nil || u.Hostname() == "" {
+				continue
+			}
+			hostnames[strings.ToLower(u.Hostname())] = struct{}{}
+		}
+	}
+	return len(hostnames)
+}
+
 func parseBackends(bs []*config.Backend) []Backend {
 	var backends []Backend
 
@@ -212,8 +635,51 @@ func parseBackends(bs []*config.Backend) []Backend {
 		if b.IsCollection {
 			v1 = addBit(v1, BackendIsCollection)
 		}
+		if isEmptyBackend(b) {
+			v1 = addBit(v1, BackendEmpty)
+		}
+		if strings.Contains(b.URLPattern, "{JWT.") {
+			v1 = addBit(v1, BackendURLWithJWTClaim)
+		}
+		// only the presence of a secret is recorded, never its value
+		if looksLikeSecret(b.URLPattern) || containsSecret(b.Host) || containsSecret(map[string]interface{}(b.ExtraConfig)) {
+			v1 = addBit(v1, BackendHardcodedSecret)
+		}
+		if isRetryWithoutBackoff(b.ExtraConfig) {
+			v1 = addBit(v1, BackendRetryWithoutBackoff)
+		}
+		if strings.EqualFold(b.Method, "GET") {
+			v1 = addBit(v1, BackendMethodGET)
+		}
+		if hasUnroutableHost(b.Host) {
+			v1 = addBit(v1, BackendUnroutableHost)
+		}
+		if b.SD == "dns" {
+			v1 = addBit(v1, BackendSDDNS)
+		}
+		if isSensitiveConnector(b.ExtraConfig) {
+			v1 = addBit(v1, BackendSensitiveConnector)
+		}
+		if isAutoAckConsumer(b.ExtraConfig) {
+			v1 = addBit(v1, BackendAMQPAutoAck)
+		}
+		if b.HostSanitizationDisabled {
+			v1 = addBit(v1, BackendHostSanitizationDisabled)
+		}
+		for _, q := range b.QueryStringsToPass {
+			if q == "*" {
+				v1 = addBit(v1, BackendQueryStringWildcard)
+				break
+			}
+		}
+		for _, h := range b.HeadersToPass {
+			if h == "*" {
+				v1 = addBit(v1, BackendHeadersWildcard)
+				break
+			}
+		}
 		backend := Backend{
-			Details:    []int{v1},
+			Details:    []int{v1, linearRetries(b.ExtraConfig)},
 			Components: parseComponents(b.ExtraConfig),
 		}
 
@@ -222,6 +688,106 @@ func parseBackends(bs []*config.Backend) []Backend {
 	return backends
 }
 
+// sensitiveConnectors are the namespaces of the backends returning the raw payload of an internal
+// system (a function or a queue) instead of the response of an HTTP API
+var sensitiveConnectors = []string{
+	"backend/lambda",
+	"github.com/devopsfaith/krakend-lambda",
+	"backend/pubsub/subscriber",
+	"github.com/devopsfaith/krakend-pubsub/subscriber",
+	"backend/amqp/consumer",
+	"github.com/devopsfaith/krakend-amqp/consume",
+}
+
+func isSensitiveConnector(cfg config.ExtraConfig) bool {
+	for _, ns := range sensitiveConnectors {
+		if _, ok := cfg[ns]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isAutoAckConsumer checks if the backend is an AMQP consumer acknowledging the messages on
+// delivery (auto_ack), before knowing if they were processed
+func isAutoAckConsumer(cfg config.ExtraConfig) bool {
+	for _, ns := range []string{"backend/amqp/consumer", "github.com/devopsfaith/krakend-amqp/consume"} {
+		v, ok := cfg[ns].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if f, ok := v["auto_ack"].(bool); ok && f {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUnroutableHost checks if the list of hosts is empty or any of them points to 0.0.0.0
+func hasUnroutableHost(hosts []string) bool {
+	if len(hosts) == 0 {
+		return true
+	}
+	for _, h := range hosts {
+		if !strings.Contains(h, "://") {
+			h = "http://" + h
+		}
+		if u, err := url.Parse(h); err == nil && u.Hostname() == "0.0.0.0" {
+			return true
+		}
+	}
+	return false
+}
+
+// isRetryWithoutBackoff checks if any of the backend components retries (max_retries) without a
+// backoff_strategy or with the "none" strategy
+func isRetryWithoutBackoff(cfg config.ExtraConfig) bool {
+	res := false
+	forEachRetry(cfg, func(_ int, strategy string) {
+		res = res || strategy == "" || strategy == "none"
+	})
+	return res
+}
+
+// linearRetries returns the highest max_retries of the backend components retrying with a linear
+// backoff_strategy, or 0 if there are none
+func linearRetries(cfg config.ExtraConfig) int {
+	res := 0
+	forEachRetry(cfg, func(retries int, strategy string) {
+		if (strategy == "linear" || strategy == "linear-jitter") && retries > res {
+			res = retries
+		}
+	})
+	return res
+}
+
+// forEachRetry calls f with the max_retries and the backoff_strategy, in lower case, of every
+// component retrying
+func forEachRetry(cfg config.ExtraConfig, f func(retries int, strategy string)) {
+	for _, v := range cfg {
+		c, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		retries, ok := c["max_retries"].(float64)
+		if !ok || retries <= 0 {
+			continue
+		}
+		strategy, _ := c["backoff_strategy"].(string)
+		f(int(retries), strings.ToLower(strategy))
+	}
+}
+
+// The bits of the modifier/response-headers component. BitResponseHeadersCacheControl is set when
+// the Cache-Control header is added or replaced
+const (
+	BitResponseHeadersDelete       int = 0
+	BitResponseHeadersAdd          int = 1
+	BitResponseHeadersRename       int = 2
+	BitResponseHeadersReplace      int = 3
+	BitResponseHeadersCacheControl int = 4
+)
+
 func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 	components := Component{}
 	for c, v := range cfg {
@@ -313,6 +879,26 @@ func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 			}
 			components[c] = res
 
+		case cb.Namespace:
+			cfg, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			// an unset max_errors is told apart from an explicit 0 with the flags
+			res := make([]int, 4)
+			if n, ok := cfg["max_errors"].(float64); ok {
+				res[0] = int(n)
+				res[3] = addBit(res[3], CircuitBreakerMaxErrors)
+			}
+			if n, ok := cfg["interval"].(float64); ok {
+				res[1] = int(n)
+			}
+			if n, ok := cfg["timeout"].(float64); ok {
+				res[2] = int(n)
+			}
+			components[c] = res
+
 		case botdetector.Namespace:
 			cfg, ok := v.(map[string]interface{})
 			if !ok {
@@ -346,35 +932,32 @@ func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 			}
 
 			v1 := 0
-			if _, ok := exp["logger"]; ok {
-				v1 = 1
-			}
-			if _, ok := exp["zipkin"]; ok {
-				v1 += 2
-			}
-			if _, ok := exp["jaeger"]; ok {
-				v1 += 4
-			}
-			if _, ok := exp["influxdb"]; ok {
-				v1 += 8
-			}
-			if _, ok := exp["prometheus"]; ok {
-				v1 += 16
-			}
-			if _, ok := exp["xray"]; ok {
-				v1 += 32
-			}
-			if _, ok := exp["stackdriver"]; ok {
-				v1 += 64
+			for name, bit := range map[string]int{
+				"logger":      OpenCensusLogger,
+				"zipkin":      OpenCensusZipkin,
+				"jaeger":      OpenCensusJaeger,
+				"influxdb":    OpenCensusInfluxDB,
+				"prometheus":  OpenCensusPrometheus,
+				"xray":        OpenCensusXRay,
+				"stackdriver": OpenCensusStackdriver,
+				"datadog":     OpenCensusDatadog,
+				"ocagent":     OpenCensusOCAgent,
+			} {
+				if _, ok := exp[name]; ok {
+					v1 = addBit(v1, bit)
+				}
 			}
-			if _, ok := exp["datadog"]; ok {
-				v1 += 128
+
+			sampleRate := -1
+			if r, ok := cfg["sample_rate"].(float64); ok {
+				sampleRate = int(r)
 			}
-			if _, ok := exp["ocagent"]; ok {
-				v1 += 256
+			insecureExporters := 0
+			if hasInsecureExporter(exp) {
+				insecureExporters = 1
 			}
 
-			components[c] = []int{v1}
+			components[c] = []int{v1, sampleRate, insecureExporters}
 
 		case ratelimit.Namespace:
 			cfg, ok := v.(map[string]interface{})
@@ -382,12 +965,14 @@ func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 				continue
 			}
 
-			v1 := 0
+			v1, maxRate, clientMaxRate := 0, 0, 0
 			if vs, ok := cfg["max_rate"].(float64); ok && vs > 0 {
 				v1 = 1
+				maxRate = int(math.Ceil(vs))
 			}
 			if vs, ok := cfg["client_max_rate"].(float64); ok && vs > 0 {
 				v1 += 2
+				clientMaxRate = int(math.Ceil(vs))
 			}
 			if vs, ok := cfg["strategy"].(string); ok {
 				switch vs {
@@ -398,7 +983,7 @@ func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 				}
 			}
 
-			components[c] = []int{v1}
+			components[c] = []int{v1, maxRate, clientMaxRate}
 		case "backend/http/client":
 			cfg, ok := v.(map[string]interface{})
 			if !ok {
@@ -450,7 +1035,12 @@ func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 			numOTLPMetrics := 0
 			numOTLPTraces := 0
 			numPrometheus := 0
+			insecureExporters := 0
+			duplicatedExporters := 0
 			if exporters, ok := cfg["exporters"].(map[string]interface{}); ok {
+				if hasInsecureExporter(exporters) {
+					insecureExporters = 1
+				}
 				if prom, ok := exporters["prometheus"].([]interface{}); ok {
 					for _, p := range prom {
 						if po, ok := p.(map[string]interface{}); ok {
@@ -461,13 +1051,29 @@ func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 					}
 				}
 				if otlp, ok := exporters["otlp"].([]interface{}); ok {
+					targets := map[string]struct{}{}
 					for _, o := range otlp {
 						if oo, ok := o.(map[string]interface{}); ok {
+							// exporters sending the same signal to the same collector are copies
+							// of each other
+							target := fmt.Sprintf("%v:%v:%v", oo["host"], oo["port"], oo["use_http"])
+							var signals []string
 							if b, ok := oo["disable_metrics"].(bool); !ok || !b {
 								numOTLPMetrics += 1
+								signals = append(signals, "metrics")
 							}
 							if b, ok := oo["disable_traces"].(bool); !ok || !b {
 								numOTLPTraces += 1
+								signals = append(signals, "traces")
+							}
+							for _, signal := range signals {
+								if _, ok := targets[signal+"@"+target]; ok {
+									duplicatedExporters++
+									break
+								}
+							}
+							for _, signal := range signals {
+								targets[signal+"@"+target] = struct{}{}
 							}
 						}
 					}
@@ -479,6 +1085,8 @@ func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 				numOTLPMetrics,         // to check if we do not have metrics
 				numOTLPTraces,          // to check if we do not have traces
 				numPrometheus,          // to check if we do not have metrics
+				insecureExporters,      // to check if we send data in clear text
+				duplicatedExporters,    // to check for copy-pasted exporters
 			}
 		case "grpc":
 			cfg, ok := v.(map[string]interface{})
@@ -495,8 +1103,13 @@ func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 				if ok {
 					numServices = len(svcs)
 				}
+				f := 0
+				if r, ok := server["reflection"].(bool); ok && r {
+					f = addBit(f, GRPCServerReflection)
+				}
 				components[c] = []int{
 					numServices, // warn about empty lists of services
+					f,
 				}
 			}
 
@@ -565,16 +1178,24 @@ func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 			}
 			v1 := 0
 			if _, ok := cfg["delete"]; ok {
-				v1 = addBit(v1, 0)
+				v1 = addBit(v1, BitResponseHeadersDelete)
 			}
 			if _, ok := cfg["add"]; ok {
-				v1 = addBit(v1, 1)
+				v1 = addBit(v1, BitResponseHeadersAdd)
 			}
 			if _, ok := cfg["rename"]; ok {
-				v1 = addBit(v1, 2)
+				v1 = addBit(v1, BitResponseHeadersRename)
 			}
 			if _, ok := cfg["replace"]; ok {
-				v1 = addBit(v1, 3)
+				v1 = addBit(v1, BitResponseHeadersReplace)
+			}
+			for _, k := range []string{"add", "replace"} {
+				headers, _ := cfg[k].(map[string]interface{})
+				for h := range headers {
+					if strings.EqualFold(h, "Cache-Control") {
+						v1 = addBit(v1, BitResponseHeadersCacheControl)
+					}
+				}
 			}
 
 			components[c] = []int{v1}
@@ -658,7 +1279,67 @@ func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 			if _, ok := cfg["post"].(string); ok {
 				f = addBit(f, 1)
 			}
+			if b, ok := cfg["allow_open_libs"].(bool); ok && b {
+				f = addBit(f, 2)
+			}
 			components[c] = []int{f}
+		case jose.ValidatorNamespace:
+			cfg, ok := v.(map[string]interface{})
+			if !ok {
+				components[c] = []int{}
+				continue
+			}
+			components[c] = []int{parseValidator(cfg)}
+		case "backend/amqp/producer":
+			cfg, ok := v.(map[string]interface{})
+			if !ok {
+				components[c] = []int{}
+				continue
+			}
+			f := 0
+			if k, ok := cfg["msg_id_key"].(string); ok && k != "" {
+				f = addBit(f, 0)
+			}
+			components[c] = []int{f}
+		case httpsecure.Namespace:
+			cfg, ok := v.(map[string]interface{})
+			if !ok {
+				components[c] = []int{}
+				continue
+			}
+			f := 0
+			if d, ok := cfg["is_development"].(bool); ok && d {
+				f = addBit(f, HTTPSecureIsDevelopment)
+			}
+			sts := 0
+			if n, ok := cfg["sts_seconds"].(float64); ok {
+				sts = int(n)
+			}
+			components[c] = []int{f, sts}
+		case gologging.Namespace:
+			cfg, ok := v.(map[string]interface{})
+			if !ok {
+				components[c] = []int{}
+				continue
+			}
+			f := 0
+			if l, ok := cfg["level"].(string); ok && strings.EqualFold(l, "DEBUG") {
+				f = addBit(f, LoggingLevelDebug)
+			}
+			if b, ok := cfg["stdout"].(bool); ok && b {
+				f = addBit(f, LoggingStdout)
+			}
+			if b, ok := cfg["syslog"].(bool); ok && b {
+				f = addBit(f, LoggingSyslog)
+			}
+			components[c] = []int{f}
+		case cors.Namespace:
+			cfg, ok := v.(map[string]interface{})
+			if !ok {
+				components[c] = []int{}
+				continue
+			}
+			components[c] = []int{parseCORS(cfg), parseCORSMaxAge(cfg)}
 		case httpcache.Namespace:
 			cfg, ok := v.(map[string]interface{})
 			if !ok {
@@ -676,6 +1357,17 @@ func parseComponents(cfg config.ExtraConfig) Component { // skipcq: GO-R1005
 				f = addBit(f, 2)
 			}
 			components[c] = []int{f}
+		case "server/static-filesystem", "backend/static-filesystem":
+			cfg, ok := v.(map[string]interface{})
+			if !ok {
+				components[c] = []int{}
+				continue
+			}
+			f := 0
+			if d, ok := cfg["directory_listing"].(bool); ok && d {
+				f = addBit(f, StaticFilesystemDirectoryListing)
+			}
+			components[c] = []int{f}
 		default:
 			components[c] = []int{}
 		}
@@ -781,6 +1473,153 @@ func parseRouter(cfg config.ExtraConfig) int {
 	return res
 }
 
+func parseValidator(cfg map[string]interface{}) int {
+	res := 0
+	alg, _ := cfg["alg"].(string)
+	alg = strings.ToUpper(alg)
+	if alg == "NONE" {
+		res = addBit(res, ValidatorAlgNone)
+	}
+	if strings.HasPrefix(alg, "HS") {
+		res = addBit(res, ValidatorAlgHMAC)
+	}
+
+	if iss, ok := cfg["issuer"].(string); ok && iss != "" {
+		res = addBit(res, ValidatorIssuer)
+	}
+	if aud, ok := cfg["audience"].([]interface{}); ok && len(aud) > 0 {
+		res = addBit(res, ValidatorAudience)
+	}
+
+	jwkURL, _ := cfg["jwk_url"].(string)
+	jwkPath, _ := cfg["jwk_local_path"].(string)
+	if jwkURL != "" && jwkPath == "" {
+		res = addBit(res, ValidatorJWKRemote)
+	}
+	if c, ok := cfg["cache"].(bool); ok && c {
+		res = addBit(res, ValidatorJWKCache)
+	}
+
+	if strings.HasPrefix(strings.ToLower(jwkURL), "http://") {
+		if u, err := url.Parse(jwkURL); err == nil && isLoopback(u.Hostname()) {
+			res = addBit(res, ValidatorJWKURLInsecureLoopback)
+		} else {
+			res = addBit(res, ValidatorJWKURLInsecure)
+		}
+	}
+	return res
+}
+
+// hasInsecureExporter checks if any of the telemetry exporters sends the data in clear text to a
+// non loopback address, either because its endpoint uses http:// or because it is flagged as
+// insecure. The exporters can be declared as objects or as lists of objects
+func hasInsecureExporter(exporters map[string]interface{}) bool {
+	for _, v := range exporters {
+		var cfgs []interface{}
+		switch t := v.(type) {
+		case []interface{}:
+			cfgs = t
+		default:
+			cfgs = []interface{}{t}
+		}
+		for _, c := range cfgs {
+			if cfg, ok := c.(map[string]interface{}); ok && isInsecureExporter(cfg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isInsecureExporter(cfg map[string]interface{}) bool {
+	insecure, _ := cfg["insecure"].(bool)
+	for _, k := range []string{"host", "endpoint", "agent_endpoint", "collector_url", "address"} {
+		addr, ok := cfg[k].(string)
+		if !ok || addr == "" {
+			continue
+		}
+		if !insecure && !strings.HasPrefix(strings.ToLower(addr), "http://") {
+			continue
+		}
+		if !strings.Contains(addr, "://") {
+			addr = "http://" + addr
+		}
+		if u, err := url.Parse(addr); err == nil && !isLoopback(u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLoopback(hostname string) bool {
+	if strings.EqualFold(hostname, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
+
+func parseCORS(cfg map[string]interface{}) int {
+	res := 0
+	// an empty list of origins allows all of them
+	origins, _ := cfg["allow_origins"].([]interface{})
+	if len(origins) == 0 {
+		res = addBit(res, CORSAllowOriginsWildcard)
+	}
+	for _, o := range origins {
+		if o == "*" {
+			res = addBit(res, CORSAllowOriginsWildcard)
+			break
+		}
+	}
+
+	if v, ok := cfg["allow_credentials"].(bool); ok && v {
+		res = addBit(res, CORSAllowCredentials)
+	}
+
+	// a wildcard or the complete list of methods is considered permissive
+	methods, _ := cfg["allow_methods"].([]interface{})
+	wildcard := false
+	declared := 0
+	for _, m := range methods {
+		name, _ := m.(string)
+		wildcard = wildcard || name == "*"
+		declared |= parseMethod(name)
+	}
+	allMethods := 0
+	for _, m := range []int{MethodGET, MethodHEAD, MethodPOST, MethodPUT, MethodPATCH, MethodDELETE} {
+		allMethods = addBit(allMethods, m)
+	}
+	if wildcard || declared&allMethods == allMethods {
+		res = addBit(res, CORSAllowMethodsPermissive)
+	}
+
+	headers, _ := cfg["allow_headers"].([]interface{})
+	if len(headers) == 0 {
+		res = addBit(res, CORSAllowHeadersEmpty)
+	}
+	for _, h := range headers {
+		if h == "*" {
+			res = addBit(res, CORSAllowHeadersWildcard)
+			break
+		}
+	}
+	return res
+}
+
+// parseCORSMaxAge returns the max_age of the CORS config in seconds, or 0 when it is unset or invalid
+func parseCORSMaxAge(cfg map[string]interface{}) int {
+	v, ok := cfg["max_age"].(string)
+	if !ok {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0
+	}
+	return int(d / time.Second)
+}
+
 func parseProxy(cfg config.ExtraConfig) int {
 	res := 0
 	v, ok := cfg["sequential"].(bool)
@@ -827,6 +1666,8 @@ func parseServerPlugin(name string) int {
 		return 8
 	case "jwk-aggregator":
 		return 9
+	case "jwt-signer":
+		return 10
 	}
 	return 0
 }
@@ -841,6 +1682,8 @@ func parseClientPlugin(name string) int {
 		return 3
 	case "http-proxy":
 		return 4
+	case "http-client-no-redirect":
+		return 5
 	}
 	return 0
 }
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileDefinition is the declarative description of an audit profile, a preset of AuditOptions
+type ProfileDefinition struct {
+	Ignore     []string `json:"ignore" yaml:"ignore"`
+	Severities []string `json:"severities" yaml:"severities"`
+	Aggregate  bool     `json:"aggregate" yaml:"aggregate"`
+	Dedupe     bool     `json:"dedupe" yaml:"dedupe"`
+}
+
+// clone returns a copy of the definition not sharing its slices, so the registered profiles can
+// not be modified through the definitions of the callers
+func (p ProfileDefinition) clone() ProfileDefinition {
+	p.Ignore = append([]string(nil), p.Ignore...)
+	p.Severities = append([]string(nil), p.Severities...)
+	return p
+}
+
+func (p ProfileDefinition) options() AuditOptions {
+	return AuditOptions{
+		Ignore:     append([]string(nil), p.Ignore...),
+		Severities: append([]string(nil), p.Severities...),
+		Aggregate:  p.Aggregate,
+		Dedupe:     p.Dedupe,
+	}
+}
+
+// The built-in profiles are:
+//
+//   - production: every rule with any severity.
+//   - development: the CRITICAL and HIGH rules, except the ones about TLS, h2c and the debug
+//     features, as they are usually enabled while developing.
+//
+// They can be replaced with RegisterProfile or LoadProfiles
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]ProfileDefinition{
+		"production": {
+			Severities: []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow},
+		},
+		"development": {
+			Ignore:     []string{"2.1.1", "2.1.2", "2.1.3", "2.1.8", "4.3.2", "5.1.2", "5.1.3"},
+			Severities: []string{SeverityCritical, SeverityHigh},
+		},
+	}
+)
+
+// LoadProfile returns the AuditOptions of the profile with the received name. See RegisterProfile
+// and LoadProfiles to define new profiles or replace the built-in ones
+func LoadProfile(name string) (AuditOptions, error) {
+	profilesMu.RLock()
+	p, ok := profiles[name]
+	profilesMu.RUnlock()
+	if !ok {
+		return AuditOptions{}, fmt.Errorf("unknown profile %q", name)
+	}
+	return p.options(), nil
+}
+
+// RegisterProfile adds a copy of the profile with the received name, replacing the existing one with
+// the same name
+func RegisterProfile(name string, p ProfileDefinition) {
+	profilesMu.Lock()
+	profiles[name] = p.clone()
+	profilesMu.Unlock()
+}
+
+// LoadProfiles parses a JSON or YAML object mapping profile names to a ProfileDefinition and
+// registers them, replacing the existing profiles with the same names. It fails without
+// registering any profile if a definition contains unknown severities
+func LoadProfiles(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var defs map[string]ProfileDefinition
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '{' {
+		err = json.Unmarshal(b, &defs)
+	} else {
+		err = yaml.Unmarshal(b, &defs)
+	}
+	if err != nil {
+		return fmt.Errorf("decoding the profiles: %w", err)
+	}
+
+	for name, def := range defs {
+		if unknown := ValidateSeverities(def.Severities); len(unknown) > 0 {
+			return fmt.Errorf("profile %s: unknown severities %v", name, unknown)
+		}
+	}
+
+	profilesMu.Lock()
+	for name, def := range defs {
+		profiles[name] = def
+	}
+	profilesMu.Unlock()
+	return nil
+}
@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	opts, err := LoadProfile("production")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(opts.Severities, AllSeverities) || len(opts.Ignore) > 0 {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	opts, err = LoadProfile("development")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(opts.Severities, []string{SeverityCritical, SeverityHigh}) {
+		t.Errorf("unexpected severities: %v", opts.Severities)
+	}
+	if unknown := ValidateIgnore(opts.Ignore); len(unknown) > 0 {
+		t.Errorf("unknown ignored rules: %v", unknown)
+	}
+
+	opts.Ignore[0] = "foo"
+	if opts, _ := LoadProfile("development"); opts.Ignore[0] == "foo" {
+		t.Error("the profile was modified through the returned options")
+	}
+
+	if _, err := LoadProfile("foo"); err == nil || err.Error() != `unknown profile "foo"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	defer restoreProfiles(t)()
+
+	for name, src := range map[string]string{
+		"json": `{"ci": {"ignore": ["1.1.1"], "severities": ["critical"], "dedupe": true}, "production": {"severities": ["CRITICAL"]}}`,
+		"yaml": `
+ci:
+  ignore: [1.1.1]
+  severities: [critical]
+  dedupe: true
+production:
+  severities: [CRITICAL]
+`,
+	} {
+		if err := LoadProfiles(strings.NewReader(src)); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		opts, err := LoadProfile("ci")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(opts, AuditOptions{Ignore: []string{"1.1.1"}, Severities: []string{"critical"}, Dedupe: true}) {
+			t.Errorf("%s: unexpected options: %+v", name, opts)
+		}
+		if opts, _ := LoadProfile("production"); !reflect.DeepEqual(opts.Severities, []string{SeverityCritical}) {
+			t.Errorf("%s: the built-in profile was not replaced: %+v", name, opts)
+		}
+	}
+
+	err := LoadProfiles(strings.NewReader(`{"staging": {"severities": ["HIGH", "URGENT"]}}`))
+	if err == nil || err.Error() != "profile staging: unknown severities [URGENT]" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := LoadProfile("staging"); err == nil {
+		t.Error("the invalid profile was registered")
+	}
+	if err := LoadProfiles(strings.NewReader(`{"staging": `)); err == nil {
+		t.Error("expecting a decoding error")
+	}
+}
+
+func TestRegisterProfile(t *testing.T) {
+	defer restoreProfiles(t)()
+
+	def := ProfileDefinition{Ignore: []string{"1.1.1"}, Severities: []string{SeverityLow}, Aggregate: true}
+	RegisterProfile("development", def)
+	def.Ignore[0] = "1.1.2"
+	def.Severities[0] = SeverityHigh
+
+	opts, err := LoadProfile("development")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(opts, AuditOptions{Ignore: []string{"1.1.1"}, Severities: []string{SeverityLow}, Aggregate: true}) {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	opts.Severities[0] = SeverityCritical
+	if opts, _ := LoadProfile("development"); opts.Severities[0] != SeverityLow {
+		t.Error("the profile was modified through the returned options")
+	}
+}
+
+func restoreProfiles(t *testing.T) func() {
+	t.Helper()
+	profilesMu.RLock()
+	backup := make(map[string]ProfileDefinition, len(profiles))
+	for k, v := range profiles {
+		backup[k] = v
+	}
+	profilesMu.RUnlock()
+	return func() {
+		profilesMu.Lock()
+		profiles = backup
+		profilesMu.Unlock()
+	}
+}
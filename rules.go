@@ -1,6 +1,13 @@
 package audit
 
 import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
 	botdetector "github.com/krakendio/krakend-botdetector/v2/krakend"
 	cb "github.com/krakendio/krakend-circuitbreaker/v2/gobreaker"
 	cors "github.com/krakendio/krakend-cors/v2"
@@ -10,10 +17,13 @@ import (
 	httpsecure "github.com/krakendio/krakend-httpsecure/v2"
 	jose "github.com/krakendio/krakend-jose/v2"
 	logstash "github.com/krakendio/krakend-logstash/v2"
+	luaproxy "github.com/krakendio/krakend-lua/v2/proxy"
+	luarouter "github.com/krakendio/krakend-lua/v2/router"
 	metrics "github.com/krakendio/krakend-metrics/v2"
 	opencensus "github.com/krakendio/krakend-opencensus/v2"
 	ratelimitProxy "github.com/krakendio/krakend-ratelimit/v3/proxy"
 	ratelimit "github.com/krakendio/krakend-ratelimit/v3/router"
+	"github.com/luraproject/lura/v2/config"
 	"github.com/luraproject/lura/v2/proxy"
 	"github.com/luraproject/lura/v2/proxy/plugin"
 	router "github.com/luraproject/lura/v2/router/gin"
@@ -21,6 +31,22 @@ import (
 	server "github.com/luraproject/lura/v2/transport/http/server/plugin"
 )
 
+// missingTLSFiles returns the TLS files of the service not found on disk. The relative paths are
+// resolved from baseDir
+func missingTLSFiles(cfg *config.ServiceConfig, baseDir string) []string {
+	var res []string
+	for _, p := range tlsFiles(cfg) {
+		path := p
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
 func hasBit(x, y int) bool {
 	return (x>>y)&1 == 1
 }
@@ -45,11 +71,21 @@ func hasBasicAuth(s *Service) bool {
 	return false
 }
 
+// hasTelemetryMissingName checks the name of the telemetry components (4.1.2), used to tell
+// the instances apart in the metrics. It is not the top level name of the service, see
+// hasServiceMissingName
 func hasTelemetryMissingName(s *Service) bool {
 	// TODO: implement this check
 	return false
 }
 
+// hasServiceMissingName checks if the top level name of the service is empty. The name identifies
+// the gateway in the logs and dashboards, and it is independent of the telemetry names checked by
+// hasTelemetryMissingName
+func hasServiceMissingName(s *Service) bool {
+	return !hasBit(s.Details[0], ServiceHasName)
+}
+
 func hasDeprecatedServerPlugin(pluginName string) func(s *Service) bool {
 	return func(s *Service) bool {
 		serverPlugins, ok := s.Components[server.Namespace]
@@ -66,36 +102,80 @@ func hasDeprecatedServerPlugin(pluginName string) func(s *Service) bool {
 	}
 }
 
-func hasDeprecatedClientPlugin(pluginName string) func(s *Service) bool {
-	return func(s *Service) bool {
-		compID := parseClientPlugin(pluginName)
-		for _, ep := range s.Endpoints {
+// hasMixedVirtualhost checks if the service declares both the deprecated virtualhost plugin and the
+// server/virtualhost component, as the precedence between them is undefined
+func hasMixedVirtualhost(s *Service) bool {
+	if _, ok := s.Components["server/virtualhost"]; !ok {
+		return false
+	}
+	return hasDeprecatedServerPlugin("virtualhost")(s)
+}
+
+// hasDeprecatedClientPlugin locates the endpoints using any of the names (aliases) of a deprecated
+// client plugin
+func hasDeprecatedClientPlugin(pluginNames ...string) func(s *Service) []Location {
+	compIDs := map[int]struct{}{}
+	for _, n := range pluginNames {
+		if id := parseClientPlugin(n); id > 0 {
+			compIDs[id] = struct{}{}
+		}
+	}
+	return func(s *Service) []Location {
+		var res []Location
+		for i, ep := range s.Endpoints {
 			comp, ok := ep.Components[client.Namespace]
-			if ok && len(comp) > 0 && comp[0] == compID {
-				return true
+			if !ok || len(comp) == 0 {
+				continue
+			}
+			if _, ok := compIDs[comp[0]]; ok {
+				res = append(res, endpointLocation(i))
 			}
 		}
-		return false
+		return res
 	}
 }
 
-func hasDeprecatedReqRespPlugin(pluginName string) func(s *Service) bool {
-	return func(s *Service) bool {
+func hasDeprecatedReqRespPlugin(pluginName string) func(s *Service) []Location {
+	return func(s *Service) []Location {
+		var res []Location
 		id := parseRespReqPlugin(pluginName)
-		for _, ep := range s.Endpoints {
+		for i, ep := range s.Endpoints {
 			comp, ok := ep.Components[plugin.Namespace]
 			if ok && hasBit(comp[0], id) {
-				return true
+				res = append(res, endpointLocation(i))
 			}
-			for _, b := range ep.Backends {
+			for j, b := range ep.Backends {
 				comp, ok := b.Components[plugin.Namespace]
 				if ok && hasBit(comp[0], id) {
-					return true
+					res = append(res, backendLocation(i, j))
 				}
 			}
 		}
-		return false
+		return res
+	}
+}
+
+func hasHardcodedSecrets(s *Service) []Location {
+	isFlagged := func(b Backend) bool {
+		return len(b.Details) > 0 && hasBit(b.Details[0], BackendHardcodedSecret)
+	}
+
+	var res []Location
+	for i, e := range s.Endpoints {
+		for j, b := range e.Backends {
+			if isFlagged(b) {
+				res = append(res, backendLocation(i, j))
+			}
+		}
+	}
+	for i, a := range s.Agents {
+		for j, b := range a.Backends {
+			if isFlagged(b) {
+				res = append(res, agentBackendLocation(i, j))
+			}
+		}
 	}
+	return res
 }
 
 func hasApiKeys(s *Service) bool {
@@ -112,6 +192,46 @@ func hasNoJWT(s *Service) bool {
 	return true
 }
 
+func hasWeakJWTAlg(alg int) func(*Service) []Location {
+	return hasValidatorFlag(alg)
+}
+
+func hasInsecureJWKURL(loopback bool) func(*Service) []Location {
+	if loopback {
+		return hasValidatorFlag(ValidatorJWKURLInsecureLoopback)
+	}
+	return hasValidatorFlag(ValidatorJWKURLInsecure)
+}
+
+func hasJWTWithoutClaimsCheck(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		v, ok := e.Components[jose.ValidatorNamespace]
+		if !ok || len(v) == 0 {
+			return false
+		}
+		return !hasBit(v[0], ValidatorIssuer) && !hasBit(v[0], ValidatorAudience)
+	})
+}
+
+func hasUncachedJWK(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		v, ok := e.Components[jose.ValidatorNamespace]
+		if !ok || len(v) == 0 {
+			return false
+		}
+		return hasBit(v[0], ValidatorJWKRemote) && !hasBit(v[0], ValidatorJWKCache)
+	})
+}
+
+func hasValidatorFlag(flag int) func(*Service) []Location {
+	return func(s *Service) []Location {
+		return endpointsMatching(s, func(e Endpoint) bool {
+			v, ok := e.Components[jose.ValidatorNamespace]
+			return ok && len(v) > 0 && hasBit(v[0], flag)
+		})
+	}
+}
+
 func hasInsecureConnections(s *Service) bool {
 	return hasBit(s.Details[0], ServiceAllowInsecureConnections)
 }
@@ -124,11 +244,38 @@ func hasTLSDisabled(s *Service) bool {
 	return hasBit(s.Details[0], ServiceHasTLS) && !hasBit(s.Details[0], ServiceTLSEnabled)
 }
 
+// hasWeakTLS returns true when the enabled TLS config accepts versions older than TLS 1.2 or
+// lists any of the insecure cipher suites
+func hasWeakTLS(s *Service) bool {
+	if !hasBit(s.Details[0], ServiceTLSEnabled) {
+		return false
+	}
+	return hasBit(s.Details[0], ServiceTLSWeakCipherSuites) || (len(s.Details) > 3 && s.Details[3] < tls.VersionTLS12)
+}
+
+// hasUnenforcedMTLS returns true when the TLS config loads the ca_certs used to verify the
+// client certificates but does not enable the mTLS
+func hasUnenforcedMTLS(s *Service) bool {
+	return hasBit(s.Details[0], ServiceTLSCaCerts) && !hasBit(s.Details[0], ServiceTLSEnableMTLS)
+}
+
 func hasNoHTTPSecure(s *Service) bool {
 	_, ok := s.Components[httpsecure.Namespace]
 	return !ok
 }
 
+func hasSecurityHTTPDevMode(s *Service) bool {
+	v, ok := s.Components[httpsecure.Namespace]
+	return ok && len(v) > 0 && hasBit(v[0], HTTPSecureIsDevelopment)
+}
+
+// hasHTTPSecureWithoutHSTS returns true when the security/http component is present but it does
+// not send the Strict-Transport-Security header, as its sts_seconds is not positive
+func hasHTTPSecureWithoutHSTS(s *Service) bool {
+	v, ok := s.Components[httpsecure.Namespace]
+	return ok && len(v) > 1 && v[1] <= 0
+}
+
 func hasH2C(s *Service) bool {
 	if hasBit(s.Details[0], ServiceUseH2C) {
 		return true
@@ -141,259 +288,1109 @@ func hasH2C(s *Service) bool {
 	return hasBit(v[0], RouterUseH2C)
 }
 
-func hasBackendInsecureConnections(s *Service) bool {
-	for _, e := range s.Endpoints {
-		for _, b := range e.Backends {
+func hasBackendInsecureConnections(s *Service) []Location {
+	var res []Location
+	for i, e := range s.Endpoints {
+		for j, b := range e.Backends {
 			v, ok := b.Components["backend/http/client"]
 			if !ok || len(v) == 0 {
 				continue
 			}
 			if hasBit(v[0], BackendComponentHTTPClientAllowInsecureConnections) {
-				return true
+				res = append(res, backendLocation(i, j))
 			}
 		}
 	}
-	return false
+	return res
 }
 
-func hasEndpointWildcard(s *Service) bool {
-	for _, e := range s.Endpoints {
-		if hasBit(e.Details[4], BitEndpointWildcard) {
-			return true
-		}
-	}
-	return false
+func hasEndpointWildcard(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		return hasBit(e.Details[4], BitEndpointWildcard)
+	})
 }
 
-func hasEndpointCatchAll(s *Service) bool {
-	for _, e := range s.Endpoints {
-		if hasBit(e.Details[4], BitEndpointCatchAll) {
-			return true
-		}
-	}
-	return false
+func hasEndpointCatchAll(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		return hasBit(e.Details[4], BitEndpointCatchAll)
+	})
 }
 
-func hasMultipleUnsafeMethods(s *Service) bool {
-	for _, e := range s.Endpoints {
-		if e.Details[5] > 1 {
-			return true
+// hasCatchAllWithoutMethod locates the wildcard and /__catchall endpoints without a method. The lura
+// parser defaults the method to GET, so once the configuration is initialized they are only
+// detected in the services parsed from the raw JSON configuration, see AuditReader
+func hasCatchAllWithoutMethod(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if len(e.Details) < 7 || !hasBit(e.Details[6], EndpointMethodMissing) {
+			return false
 		}
-	}
-	return false
+		return hasBit(e.Details[4], BitEndpointWildcard) || hasBit(e.Details[4], BitEndpointCatchAll)
+	})
 }
 
-func hasSequentialProxy(s *Service) bool {
-	for _, e := range s.Endpoints {
-		p, ok := e.Components[proxy.Namespace]
-		if ok && len(p) > 0 && hasBit(p[0], 0) {
-			return true
-		}
-	}
-	return false
+// hasEmptyEndpoint locates the endpoints without extra_config where every backend is empty, usually
+// leftovers of blocks commented out with @comment keys that still parse. When the service declares a
+// host, they are only detected in the services parsed from the raw JSON configuration, see
+// AuditReader
+func hasEmptyEndpoint(s *Service) []Location {
+	return endpointsMatching(s, isEmptyEndpoint)
 }
 
-func hasQueryStringWildcard(s *Service) bool {
-	for _, e := range s.Endpoints {
-		if hasBit(e.Details[4], 1) {
-			return true
+func isEmptyEndpoint(e Endpoint) bool {
+	if len(e.Backends) == 0 || len(e.Components) > 0 {
+		return false
+	}
+	for _, b := range e.Backends {
+		if len(b.Details) == 0 || !hasBit(b.Details[0], BackendEmpty) {
+			return false
 		}
 	}
-	return false
+	return true
 }
 
-func hasHeadersWildcard(s *Service) bool {
-	for _, e := range s.Endpoints {
-		if hasBit(e.Details[4], 2) {
-			return true
-		}
-	}
-	return false
+func hasMultipleUnsafeMethods(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		return e.Details[5] > 1
+	})
 }
 
-func hasNoObfuscatedVersionHeader(s *Service) bool {
-	v, ok := s.Components[router.Namespace]
-	if !ok || len(v) == 0 {
-		return true
-	}
-	return !hasBit(v[0], RouterHideVersionHeader)
+func hasSequentialProxy(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		p, ok := e.Components[proxy.Namespace]
+		return ok && len(p) > 0 && hasBit(p[0], 0)
+	})
 }
 
-func hasNoCORS(s *Service) bool {
-	_, ok := s.Components[cors.Namespace]
-	return !ok
+// hasSequentialProxyWithoutErrorDetails locates the sequential endpoints where no backend declares
+// return_error_details or return_error_code, hiding which step of the chain failed
+func hasSequentialProxyWithoutErrorDetails(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		p, ok := e.Components[proxy.Namespace]
+		if !ok || len(p) == 0 || !hasBit(p[0], 0) {
+			return false
+		}
+		return len(e.Details) > 6 && !hasBit(e.Details[6], EndpointReturnErrorDetails)
+	})
 }
 
-func hasBotdetectorDisabled(s *Service) bool {
-	_, ok := s.Components[botdetector.Namespace]
-	return !ok
+func hasMissingContentTypeForward(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if len(e.Details) < 7 || e.Details[2] == 0 || hasBit(e.Details[4], BitEndpointHeaderStringWildcard) {
+			return false
+		}
+		isWrite := hasBit(e.Details[6], MethodPOST) || hasBit(e.Details[6], MethodPUT) || hasBit(e.Details[6], MethodPATCH)
+		return isWrite && !hasBit(e.Details[6], EndpointInputHeaderContentType)
+	})
 }
 
-func hasNoRatelimit(s *Service) bool {
-	_, ok := s.Components[ratelimit.Namespace]
-	if ok {
-		return false
-	}
-	for _, e := range s.Endpoints {
-		_, ok := e.Components[ratelimit.Namespace]
-		if ok {
+// hasWriteEndpointWithGETBackend locates the POST, PUT and PATCH endpoints with a single backend
+// called with the GET method, as the request body is dropped. Endpoints generating the backend
+// request body (modifier/body-generator) map the methods on purpose and they are skipped
+func hasWriteEndpointWithGETBackend(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if len(e.Details) < 7 || len(e.Backends) != 1 {
 			return false
 		}
-		_, ok = e.Components[ratelimitProxy.Namespace]
-		if ok {
+		isWrite := hasBit(e.Details[6], MethodPOST) || hasBit(e.Details[6], MethodPUT) || hasBit(e.Details[6], MethodPATCH)
+		b := e.Backends[0]
+		if !isWrite || len(b.Details) == 0 || !hasBit(b.Details[0], BackendMethodGET) {
 			return false
 		}
-		for _, b := range e.Backends {
-			_, ok := b.Components[ratelimitProxy.Namespace]
-			if ok {
-				return false
+		_, ok1 := e.Components["modifier/body-generator"]
+		_, ok2 := b.Components["modifier/body-generator"]
+		return !ok1 && !ok2
+	})
+}
+
+// hasUnroutableBackendHost locates the backends of the endpoints and the async agents without hosts
+// or with a host pointing to 0.0.0.0. The backends of the empty endpoints are skipped, as
+// hasEmptyEndpoint already reports them
+func hasUnroutableBackendHost(s *Service) []Location {
+	isUnroutable := func(b Backend) bool {
+		return len(b.Details) > 0 && hasBit(b.Details[0], BackendUnroutableHost)
+	}
+
+	var res []Location
+	for i, e := range s.Endpoints {
+		if isEmptyEndpoint(e) {
+			continue
+		}
+		for j, b := range e.Backends {
+			if isUnroutable(b) {
+				res = append(res, backendLocation(i, j))
+			}
+		}
+	}
+	for i, a := range s.Agents {
+		for j, b := range a.Backends {
+			if isUnroutable(b) {
+				res = append(res, agentBackendLocation(i, j))
 			}
 		}
 	}
+	return res
+}
 
-	_, ok = s.Components["qos/ratelimit/service"]
-	if ok {
-		return false
+// hasMisconfiguredDNSSD locates the backends using the DNS SRV service discovery without
+// disable_host_sanitize, as the sanitization turns the SRV names into URLs that cannot be resolved
+func hasMisconfiguredDNSSD(s *Service) []Location {
+	isMisconfigured := func(b Backend) bool {
+		return len(b.Details) > 0 && hasBit(b.Details[0], BackendSDDNS) && !hasBit(b.Details[0], BackendHostSanitizationDisabled)
 	}
 
-	serverPlugins, ok := s.Components[server.Namespace]
-	if ok && len(serverPlugins) > 0 {
-		pluginsBitset := serverPlugins[0]
-		redisRateLimitBit := parseServerPlugin("redis-ratelimit")
-		if hasBit(pluginsBitset, redisRateLimitBit) {
-			return false
+	var res []Location
+	for i, e := range s.Endpoints {
+		for j, b := range e.Backends {
+			if isMisconfigured(b) {
+				res = append(res, backendLocation(i, j))
+			}
 		}
 	}
-
-	return true
+	for i, a := range s.Agents {
+		for j, b := range a.Backends {
+			if isMisconfigured(b) {
+				res = append(res, agentBackendLocation(i, j))
+			}
+		}
+	}
+	return res
 }
 
-func hasNoCB(s *Service) bool {
-	for _, e := range s.Endpoints {
-		_, ok := e.Components[cb.Namespace]
-		if ok {
-			return false
+// hasUnsanitizedBackendHost locates the backends with disable_host_sanitize, as their hosts are
+// used without validation. The backends using the DNS SRV service discovery need it, so they are
+// not reported (see hasMisconfiguredDNSSD)
+func hasUnsanitizedBackendHost(s *Service) []Location {
+	isUnsanitized := func(b Backend) bool {
+		return len(b.Details) > 0 && hasBit(b.Details[0], BackendHostSanitizationDisabled) && !hasBit(b.Details[0], BackendSDDNS)
+	}
+
+	var res []Location
+	for i, e := range s.Endpoints {
+		for j, b := range e.Backends {
+			if isUnsanitized(b) {
+				res = append(res, backendLocation(i, j))
+			}
 		}
-		for _, b := range e.Backends {
-			_, ok := b.Components[cb.Namespace]
-			if ok {
-				return false
+	}
+	for i, a := range s.Agents {
+		for j, b := range a.Backends {
+			if isUnsanitized(b) {
+				res = append(res, agentBackendLocation(i, j))
 			}
 		}
 	}
-	return true
+	return res
 }
 
-func hasTimeoutBiggerThan(d int) func(*Service) bool {
-	return func(s *Service) bool {
-		for _, e := range s.Endpoints {
-			if e.Details[3] > d {
+// hasUnfilteredSensitiveConnector locates the endpoints with lambda, pub/sub or AMQP consumer
+// backends returning their payload without filtering it with allow, deny or mapping
+func hasUnfilteredSensitiveConnector(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		for _, b := range e.Backends {
+			if len(b.Details) == 0 || !hasBit(b.Details[0], BackendSensitiveConnector) {
+				continue
+			}
+			if !hasBit(b.Details[0], BackendAllow) && !hasBit(b.Details[0], BackendDeny) && !hasBit(b.Details[0], BackendMapping) {
 				return true
 			}
 		}
 		return false
-	}
+	})
 }
 
-func hasNoMetrics(s *Service) bool {
-	for _, k := range []string{
-		opencensus.Namespace,
-		metrics.Namespace,
-		"telemetry/newrelic",
-		"telemetry/ganalytics",
-		"telemetry/instana",
-	} {
-		if _, ok := s.Components[k]; ok {
+// hasAggregatedStringBackend locates the endpoints aggregating several backends where any of them
+// uses the string encoding, as its response is not a JSON object that can be merged with the rest
+func hasAggregatedStringBackend(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if len(e.Backends) < 2 {
 			return false
 		}
-	}
-	return true
+		for _, b := range e.Backends {
+			if len(b.Details) > 0 && hasBit(b.Details[0], EncodingSTRING) {
+				return true
+			}
+		}
+		return false
+	})
 }
 
-func hasSeveralTelemetryComponents(s *Service) bool {
-	tot := 0
-	for _, k := range []string{
-		opencensus.Namespace,
-		metrics.Namespace,
-		"telemetry/newrelic",
-		"telemetry/ganalytics",
-		"telemetry/instana",
-	} {
-		if _, ok := s.Components[k]; ok {
-			tot++
+// hasJWTWithoutAuthorizationHeader locates the endpoints validating JWT with an explicit list of
+// input_headers that does not include the Authorization header, so their HTTP backends never
+// receive the token. It is a heuristic: only the endpoints with a backend that could check the
+// token are considered, skipping the ones calling lambda, pub/sub and AMQP connectors, but the
+// audit can not tell if the backends actually need it
+func hasJWTWithoutAuthorizationHeader(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if _, ok := e.Components[jose.ValidatorNamespace]; !ok || len(e.Details) < 7 {
+			return false
 		}
-	}
+		if e.Details[2] == 0 || hasBit(e.Details[4], BitEndpointHeaderStringWildcard) {
+			return false
+		}
+		if hasBit(e.Details[6], EndpointInputHeaderAuthorization) {
+			return false
+		}
+		for _, b := range e.Backends {
+			if len(b.Details) > 0 && !hasBit(b.Details[0], BackendSensitiveConnector) {
+				return true
+			}
+		}
+		return false
+	})
+}
 
-	otel, okOTEL := s.Components["telemetry/opentelemetry"]
-	if okOTEL && len(otel) >= 5 {
-		// OTL enabled metrics + prometheus
-		tot += otel[2] + otel[4]
-	}
-	return tot > 1
+func hasWildcardMethod(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		return len(e.Details) > 6 && hasBit(e.Details[6], EndpointMethodWildcard)
+	})
 }
 
-func hasNoTracing(s *Service) bool {
-	_, ok1 := s.Components[opencensus.Namespace]
-	_, ok2 := s.Components["telemetry/newrelic"]
-	_, ok3 := s.Components["telemetry/instana"]
+func hasQueryStringWildcard(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		return hasBit(e.Details[4], BitEndpointQueryStringWildcard)
+	})
+}
 
-	otel, okOTEL := s.Components["telemetry/opentelemetry"]
-	if okOTEL {
-		// in position 3 we have number of enabled OTEL exporters for traces:
-		if len(otel) < 4 || otel[3] < 1 {
-			okOTEL = false
+// hasBackendQueryStringWildcard locates the backends forwarding all the query strings. The endpoints
+// with a wildcard in their own input_query_strings are skipped, as hasQueryStringWildcard reports them
+func hasBackendQueryStringWildcard(s *Service) []Location {
+	var res []Location
+	for i, e := range s.Endpoints {
+		if hasBit(e.Details[4], BitEndpointQueryStringWildcard) {
+			continue
+		}
+		for j, b := range e.Backends {
+			if len(b.Details) > 0 && hasBit(b.Details[0], BackendQueryStringWildcard) {
+				res = append(res, backendLocation(i, j))
+			}
 		}
 	}
-	return !ok1 && !ok2 && !ok3 && !okOTEL
-}
-
-func hasDeprecatedInstana(s *Service) bool {
-	_, ok := s.Components["telemetry/instana"]
-	return ok
+	return res
 }
 
-func hasDeprecatedGanalytics(s *Service) bool {
-	_, ok := s.Components["telemetry/ganalytics"]
-	return ok
+// hasBackendHeadersWildcard locates the backends forwarding all the headers. The endpoints with a
+// wildcard in their own input_headers are skipped, as hasHeadersWildcard reports them
+func hasBackendHeadersWildcard(s *Service) []Location {
+	var res []Location
+	for i, e := range s.Endpoints {
+		if hasBit(e.Details[4], BitEndpointHeaderStringWildcard) {
+			continue
+		}
+		for j, b := range e.Backends {
+			if len(b.Details) > 0 && hasBit(b.Details[0], BackendHeadersWildcard) {
+				res = append(res, backendLocation(i, j))
+			}
+		}
+	}
+	return res
 }
 
-func hasDeprecatedOpenCensus(s *Service) bool {
-	_, ok := s.Components[opencensus.Namespace]
-	return ok
+func hasHeadersWildcard(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		return hasBit(e.Details[4], BitEndpointHeaderStringWildcard)
+	})
 }
 
-func hasDeprecatedTLSPrivPubKey(s *Service) bool {
-	return hasBit(s.Details[0], ServiceTLSPrivPubKey)
+// hasHighConcurrentCalls locates the endpoints with a concurrent_calls higher than max, as they
+// multiply the load on their backends
+func hasHighConcurrentCalls(max int) func(*Service) []Location {
+	return func(s *Service) []Location {
+		var res []Location
+		for i, e := range s.Endpoints {
+			if len(e.Details) < 10 || e.Details[9] <= max {
+				continue
+			}
+			l := endpointLocation(i)
+			l.Detail = fmt.Sprintf("concurrent_calls: %d", e.Details[9])
+			res = append(res, l)
+		}
+		return res
+	}
 }
 
-func hasNoLogging(s *Service) bool {
-	_, ok1 := s.Components[gologging.Namespace]
-	_, ok2 := s.Components[gelf.Namespace]
-	_, ok3 := s.Components[logstash.Namespace]
-	return !ok1 && !ok2 && !ok3
+// hasOverlappingEndpoints locates the endpoints shadowed by, or duplicating, a previous endpoint
+// declared with the same method. The location details the overlapped endpoint
+func hasOverlappingEndpoints(s *Service) []Location {
+	var res []Location
+	for i, e := range s.Endpoints {
+		if len(e.Details) < 11 || e.Details[10] == 0 {
+			continue
+		}
+		l := endpointLocation(i)
+		l.Detail = fmt.Sprintf("overlaps endpoints[%d]", e.Details[10]-1)
+		res = append(res, l)
+	}
+	return res
 }
 
-func hasRestfulDisabled(s *Service) bool {
-	return hasBit(s.Details[0], ServiceDisableStrictREST)
+func endpointsMatching(s *Service, f func(Endpoint) bool) []Location {
+	var res []Location
+	for i, e := range s.Endpoints {
+		if f(e) {
+			res = append(res, endpointLocation(i))
+		}
+	}
+	return res
 }
 
-func hasDebugEnabled(s *Service) bool {
-	return hasBit(s.Details[0], ServiceDebug)
+func hasNoObfuscatedVersionHeader(s *Service) bool {
+	v, ok := s.Components[router.Namespace]
+	if !ok || len(v) == 0 {
+		return true
+	}
+	return !hasBit(v[0], RouterHideVersionHeader)
 }
 
+// hasNoHealthCheck returns true when the built-in health endpoint is disabled and none of the
+// declared endpoints looks like a health check
+func hasNoHealthCheck(s *Service) bool {
+	v, ok := s.Components[router.Namespace]
+	if !ok || len(v) == 0 || !hasBit(v[0], RouterDisableHealth) {
+		return false
+	}
+	for _, e := range s.Endpoints {
+		if len(e.Details) > 6 && hasBit(e.Details[6], EndpointHealthCheck) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasNoCORS(s *Service) bool {
+	_, ok := s.Components[cors.Namespace]
+	return !ok
+}
+
+func hasInsecureCORSCredentials(s *Service) bool {
+	v, ok := s.Components[cors.Namespace]
+	if !ok || len(v) == 0 {
+		return false
+	}
+	return hasBit(v[0], CORSAllowOriginsWildcard) && hasBit(v[0], CORSAllowCredentials)
+}
+
+// hasPermissiveCORSHeaders returns true when the CORS allow_headers contains a wildcard, or when it
+// is empty and the credentials are allowed
+func hasPermissiveCORSHeaders(s *Service) bool {
+	v, ok := s.Components[cors.Namespace]
+	if !ok || len(v) == 0 {
+		return false
+	}
+	return hasBit(v[0], CORSAllowHeadersWildcard) || (hasBit(v[0], CORSAllowHeadersEmpty) && hasBit(v[0], CORSAllowCredentials))
+}
+
+// hasNoCORSMaxAge returns true when the CORS preflight responses are not cached by the browsers, so
+// every cross-origin request requires a preflight one
+func hasNoCORSMaxAge(s *Service) bool {
+	v, ok := s.Components[cors.Namespace]
+	return ok && len(v) > 1 && v[1] <= 0
+}
+
+func hasPermissiveCORSMethods(s *Service) bool {
+	v, ok := s.Components[cors.Namespace]
+	return ok && len(v) > 0 && hasBit(v[0], CORSAllowMethodsPermissive)
+}
+
+// hasLargeIdleConnectionPool checks if max_idle_connections or max_idle_connections_per_host are
+// higher than max
+func hasLargeIdleConnectionPool(max int) func(*Service) bool {
+	return func(s *Service) bool {
+		if len(s.Details) < 3 {
+			return false
+		}
+		return s.Details[1] > max || s.Details[2] > max
+	}
+}
+
+// hasDuplicatedNamespace locates the service, endpoints, backends and async agents declaring the same
+// namespace twice in their extra_config. Only the services parsed from the raw JSON configuration
+// record it, see AuditReader
+func hasDuplicatedNamespace(s *Service) []Location {
+	var res []Location
+	if len(s.Details) > 0 && hasBit(s.Details[0], ServiceDuplicatedNamespace) {
+		res = append(res, serviceLocation())
+	}
+	for i, e := range s.Endpoints {
+		if len(e.Details) > 6 && hasBit(e.Details[6], EndpointDuplicatedNamespace) {
+			res = append(res, endpointLocation(i))
+		}
+		for j, b := range e.Backends {
+			if len(b.Details) > 0 && hasBit(b.Details[0], BackendDuplicatedNamespace) {
+				res = append(res, backendLocation(i, j))
+			}
+		}
+	}
+	for i, a := range s.Agents {
+		if len(a.Details) > 5 && hasBit(a.Details[5], AgentDuplicatedNamespace) {
+			res = append(res, agentLocation(i))
+		}
+		for j, b := range a.Backends {
+			if len(b.Details) > 0 && hasBit(b.Details[0], BackendDuplicatedNamespace) {
+				res = append(res, agentBackendLocation(i, j))
+			}
+		}
+	}
+	return res
+}
+
+func hasBotdetectorDisabled(s *Service) bool {
+	_, ok := s.Components[botdetector.Namespace]
+	return !ok
+}
+
+func hasIneffectiveBotdetector(s *Service) bool {
+	v, ok := s.Components[botdetector.Namespace]
+	if !ok || len(v) < 4 {
+		return false
+	}
+	// [allow, deny, patterns, cache_size]
+	return v[1] == 0 && v[2] == 0 && v[3] == 0
+}
+
+func hasNoRatelimit(s *Service) bool {
+	if hasServiceRatelimit(s) {
+		return false
+	}
+	return len(endpointsWithoutRatelimit(s)) == len(s.Endpoints)
+}
+
+func hasServiceRatelimit(s *Service) bool {
+	_, ok := s.Components[ratelimit.Namespace]
+	if ok {
+		return true
+	}
+
+	_, ok = s.Components["qos/ratelimit/service"]
+	if ok {
+		return true
+	}
+
+	serverPlugins, ok := s.Components[server.Namespace]
+	if ok && len(serverPlugins) > 0 {
+		pluginsBitset := serverPlugins[0]
+		redisRateLimitBit := parseServerPlugin("redis-ratelimit")
+		if hasBit(pluginsBitset, redisRateLimitBit) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func endpointsWithoutRatelimit(s *Service) []Location {
+	if hasServiceRatelimit(s) {
+		return nil
+	}
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if _, ok := e.Components[ratelimit.Namespace]; ok {
+			return false
+		}
+		if _, ok := e.Components[ratelimitProxy.Namespace]; ok {
+			return false
+		}
+		for _, b := range e.Backends {
+			if _, ok := b.Components[ratelimitProxy.Namespace]; ok {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// protectionNamespacePrefixes are the prefixes of the namespaces adding authentication, rate
+// limits or input validation to an endpoint or its backends
+var protectionNamespacePrefixes = []string{"auth/", "qos/ratelimit/", "validation/"}
+
+// isProtectionComponent checks if any component authenticates, rate limits or validates the
+// traffic, including the legacy JWT validator namespace. Other security components, like CORS or
+// the HTTP security headers, do not protect a write endpoint from its callers
+func isProtectionComponent(c Component) bool {
+	for ns := range c {
+		for _, p := range protectionNamespacePrefixes {
+			if strings.HasPrefix(ns, p) {
+				return true
+			}
+		}
+		if ns == jose.ValidatorNamespace {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUnprotectedWriteEndpoint locates the POST, PUT, PATCH and DELETE endpoints without any
+// authentication, rate limit or validation component, neither in the endpoint nor in its
+// backends. A service level rate limit protects every endpoint, so they are not reported in that
+// case
+func hasUnprotectedWriteEndpoint(s *Service) []Location {
+	if hasServiceRatelimit(s) {
+		return nil
+	}
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if len(e.Details) < 7 {
+			return false
+		}
+		if !hasBit(e.Details[6], MethodPOST) && !hasBit(e.Details[6], MethodPUT) && !hasBit(e.Details[6], MethodPATCH) && !hasBit(e.Details[6], MethodDELETE) {
+			return false
+		}
+		if isProtectionComponent(e.Components) {
+			return false
+		}
+		for _, b := range e.Backends {
+			if isProtectionComponent(b.Components) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// hasLuaOpenLibs reports every Lua component with allow_open_libs, as the scripts can access the
+// filesystem and run commands outside of the sandbox
+func hasLuaOpenLibs(s *Service) []Location {
+	allowsOpenLibs := func(c Component) bool {
+		for _, ns := range []string{luarouter.Namespace, luaproxy.ProxyNamespace, luaproxy.BackendNamespace} {
+			if v, ok := c[ns]; ok && len(v) > 0 && hasBit(v[0], 2) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var res []Location
+	if allowsOpenLibs(s.Components) {
+		res = append(res, serviceLocation())
+	}
+	for i, e := range s.Endpoints {
+		if allowsOpenLibs(e.Components) {
+			res = append(res, endpointLocation(i))
+		}
+		for j, b := range e.Backends {
+			if allowsOpenLibs(b.Components) {
+				res = append(res, backendLocation(i, j))
+			}
+		}
+	}
+	return res
+}
+
+func hasIPRatelimitWithoutPrivacy(s *Service) []Location {
+	isIPStrategy := func(c Component) bool {
+		v, ok := c[ratelimit.Namespace]
+		return ok && len(v) > 0 && hasBit(v[0], 2)
+	}
+
+	var res []Location
+	if isIPStrategy(s.Components) {
+		res = append(res, serviceLocation())
+	}
+	return append(res, endpointsMatching(s, func(e Endpoint) bool {
+		return isIPStrategy(e.Components)
+	})...)
+}
+
+func hasIneffectiveRatelimit(s *Service) []Location {
+	isIneffective := func(c Component) bool {
+		v, ok := c[ratelimit.Namespace]
+		return ok && len(v) > 0 && !hasBit(v[0], 0) && !hasBit(v[0], 1)
+	}
+
+	var res []Location
+	if isIneffective(s.Components) {
+		res = append(res, serviceLocation())
+	}
+	return append(res, endpointsMatching(s, func(e Endpoint) bool {
+		return isIneffective(e.Components)
+	})...)
+}
+
+func hasRouterRatelimitWithoutClientLimit(s *Service) []Location {
+	isGlobalOnly := func(c Component) bool {
+		v, ok := c[ratelimit.Namespace]
+		if !ok || len(v) == 0 || !hasBit(v[0], 0) {
+			return false
+		}
+		return !hasBit(v[0], 1) && !hasBit(v[0], 2) && !hasBit(v[0], 3)
+	}
+
+	var res []Location
+	if isGlobalOnly(s.Components) {
+		res = append(res, serviceLocation())
+	}
+	return append(res, endpointsMatching(s, func(e Endpoint) bool {
+		return isGlobalOnly(e.Components)
+	})...)
+}
+
+// hasMisconfiguredCB locates the circuit breakers declaring a max_errors that opens them on the
+// first failure or staying open longer than maxTimeout, keeping the backend unavailable for too long
+func hasMisconfiguredCB(maxTimeout time.Duration) func(*Service) []Location {
+	maxSeconds := int(maxTimeout / time.Second)
+	isMisconfigured := func(c Component) bool {
+		v, ok := c[cb.Namespace]
+		if !ok || len(v) < 4 {
+			return false
+		}
+		// [max_errors, interval, timeout, flags], in seconds
+		return (hasBit(v[3], CircuitBreakerMaxErrors) && v[0] <= 0) || v[2] > maxSeconds
+	}
+
+	return func(s *Service) []Location {
+		var res []Location
+		for i, e := range s.Endpoints {
+			if isMisconfigured(e.Components) {
+				res = append(res, endpointLocation(i))
+			}
+			for j, b := range e.Backends {
+				if isMisconfigured(b.Components) {
+					res = append(res, backendLocation(i, j))
+				}
+			}
+		}
+		for i, a := range s.Agents {
+			for j, b := range a.Backends {
+				if isMisconfigured(b.Components) {
+					res = append(res, agentBackendLocation(i, j))
+				}
+			}
+		}
+		return res
+	}
+}
+
+// hasRetryWithoutBackoff locates the backends retrying without a backoff strategy, or with a linear
+// one and more than maxLinearRetries retries
+func hasRetryWithoutBackoff(maxLinearRetries int) func(*Service) []Location {
+	return func(s *Service) []Location {
+		var res []Location
+		for i, e := range s.Endpoints {
+			for j, b := range e.Backends {
+				if len(b.Details) > 0 && hasBit(b.Details[0], BackendRetryWithoutBackoff) {
+					res = append(res, backendLocation(i, j))
+					continue
+				}
+				if len(b.Details) > 1 && b.Details[1] > maxLinearRetries {
+					res = append(res, backendLocation(i, j))
+				}
+			}
+		}
+		return res
+	}
+}
+
+func hasNoCB(s *Service) bool {
+	return len(endpointsWithoutCB(s)) == len(s.Endpoints)
+}
+
+func endpointsWithoutCB(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if _, ok := e.Components[cb.Namespace]; ok {
+			return false
+		}
+		for _, b := range e.Backends {
+			if _, ok := b.Components[cb.Namespace]; ok {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// hasTimeoutBetween locates the endpoints with a timeout bigger than from and up to to, both in
+// milliseconds, so every endpoint is reported only by the tier matching its timeout. A to lower
+// than 1 removes the upper bound. The locations include the actual timeout
+func hasTimeoutBetween(from, to int) func(*Service) []Location {
+	return func(s *Service) []Location {
+		var res []Location
+		for i, e := range s.Endpoints {
+			t := e.Details[3]
+			if t <= from || (to > 0 && t > to) {
+				continue
+			}
+			l := endpointLocation(i)
+			l.Detail = fmt.Sprintf("timeout: %s", time.Duration(t)*time.Millisecond)
+			res = append(res, l)
+		}
+		return res
+	}
+}
+
+// serverTimeouts maps the server timeouts with the bits flagging them in the service details
+var serverTimeouts = []struct {
+	name string
+	bit  int
+}{
+	{"read_timeout", ServiceReadTimeout},
+	{"write_timeout", ServiceWriteTimeout},
+	{"idle_timeout", ServiceIdleTimeout},
+}
+
+// hasMissingServerTimeouts locates the service when it does not declare some of the server
+// timeouts, leaving the connections open to slow clients. When all is set, it only reports the
+// service if every timeout is missing, otherwise it only reports the partial configurations. The
+// location lists the missing timeouts
+func hasMissingServerTimeouts(all bool) func(*Service) []Location {
+	return func(s *Service) []Location {
+		var missing []string
+		for _, t := range serverTimeouts {
+			if !hasBit(s.Details[0], t.bit) {
+				missing = append(missing, t.name)
+			}
+		}
+		if len(missing) == 0 || all != (len(missing) == len(serverTimeouts)) {
+			return nil
+		}
+		l := serviceLocation()
+		l.Detail = "missing: " + strings.Join(missing, ", ")
+		return []Location{l}
+	}
+}
+
+// hasUnboundedHeaderBytes locates the service when its max_header_bytes is unset or higher than
+// max. The location includes the value when it is too large
+func hasUnboundedHeaderBytes(max int) func(*Service) []Location {
+	return func(s *Service) []Location {
+		if len(s.Details) < 7 || (s.Details[6] > 0 && s.Details[6] <= max) {
+			return nil
+		}
+		l := serviceLocation()
+		if s.Details[6] > 0 {
+			l.Detail = fmt.Sprintf("max_header_bytes: %d", s.Details[6])
+		}
+		return []Location{l}
+	}
+}
+
+// hasOversizedServiceTimeout locates the service when its timeout is more than ratio times the
+// longest endpoint timeout. The endpoints without a timeout inherit the service one, so in that
+// case the service timeout is never reported. The location includes both timeouts
+func hasOversizedServiceTimeout(ratio int) func(*Service) []Location {
+	return func(s *Service) []Location {
+		if len(s.Details) < 6 || s.Details[5] <= 0 || len(s.Endpoints) == 0 {
+			return nil
+		}
+		longest := 0
+		for _, e := range s.Endpoints {
+			if len(e.Details) > 3 && e.Details[3] > longest {
+				longest = e.Details[3]
+			}
+		}
+		if longest <= 0 || s.Details[5] <= ratio*longest {
+			return nil
+		}
+		l := serviceLocation()
+		l.Detail = fmt.Sprintf("timeout: %s, longest endpoint timeout: %s", time.Duration(s.Details[5])*time.Millisecond, time.Duration(longest)*time.Millisecond)
+		return []Location{l}
+	}
+}
+
+// hasAggregationWithoutTimeout locates the endpoints aggregating several backends when neither the
+// endpoint nor the service declare a timeout. The lura parser sets a default timeout, so once the
+// configuration is initialized it is only detected in the services parsed from the raw JSON
+// configuration, see AuditReader
+func hasAggregationWithoutTimeout(s *Service) []Location {
+	if len(s.Details) == 0 || hasBit(s.Details[0], ServiceTimeout) {
+		return nil
+	}
+	return endpointsMatching(s, func(e Endpoint) bool {
+		return len(e.Backends) > 1 && len(e.Details) > 6 && hasBit(e.Details[6], EndpointTimeoutMissing)
+	})
+}
+
+func hasNoMetrics(s *Service) bool {
+	for _, k := range []string{
+		opencensus.Namespace,
+		metrics.Namespace,
+		"telemetry/newrelic",
+		"telemetry/ganalytics",
+		"telemetry/instana",
+	} {
+		if _, ok := s.Components[k]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// hasNoOpenTelemetry checks if the service does not declare telemetry/opentelemetry, whatever the
+// other telemetry components it uses
+func hasNoOpenTelemetry(s *Service) bool {
+	_, ok := s.Components["telemetry/opentelemetry"]
+	return !ok
+}
+
+func hasSeveralTelemetryComponents(s *Service) bool {
+	tot := 0
+	for _, k := range []string{
+		opencensus.Namespace,
+		metrics.Namespace,
+		"telemetry/newrelic",
+		"telemetry/ganalytics",
+		"telemetry/instana",
+	} {
+		if _, ok := s.Components[k]; ok {
+			tot++
+		}
+	}
+
+	otel, okOTEL := s.Components["telemetry/opentelemetry"]
+	if okOTEL && len(otel) >= 5 {
+		// OTL enabled metrics + prometheus
+		tot += otel[2] + otel[4]
+	}
+	return tot > 1
+}
+
+func hasNoTracing(s *Service) bool {
+	_, ok1 := s.Components[opencensus.Namespace]
+	_, ok2 := s.Components["telemetry/newrelic"]
+	_, ok3 := s.Components["telemetry/instana"]
+
+	otel, okOTEL := s.Components["telemetry/opentelemetry"]
+	if okOTEL {
+		// in position 3 we have number of enabled OTEL exporters for traces:
+		if len(otel) < 4 || otel[3] < 1 {
+			okOTEL = false
+		}
+	}
+	return !ok1 && !ok2 && !ok3 && !okOTEL
+}
+
+// defaultTraceSampleRate is the sample rate, as a percentage, assumed for the traces when the
+// telemetry configuration does not declare it
+var defaultTraceSampleRate = 100
+
+// hasFullTraceSampling checks if the OpenTelemetry (trace_sample_rate) or the OpenCensus
+// (sample_rate) traces are sampled at 100%. A missing rate counts as defaultTraceSampleRate
+func hasFullTraceSampling(s *Service) bool {
+	rateOf := func(v int) int {
+		if v < 0 {
+			return defaultTraceSampleRate
+		}
+		return v
+	}
+
+	// the opentelemetry rate is only relevant when there are exporters for traces
+	if otel, ok := s.Components["telemetry/opentelemetry"]; ok && len(otel) >= 4 && otel[3] > 0 {
+		if rateOf(otel[1]) >= 100 {
+			return true
+		}
+	}
+	if oc, ok := s.Components[opencensus.Namespace]; ok && len(oc) >= 2 {
+		if rateOf(oc[1]) >= 100 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasInsecureTelemetryExporter(s *Service) bool {
+	if otel, ok := s.Components["telemetry/opentelemetry"]; ok && len(otel) >= 6 && otel[5] > 0 {
+		return true
+	}
+	oc, ok := s.Components[opencensus.Namespace]
+	return ok && len(oc) >= 3 && oc[2] > 0
+}
+
+// hasDuplicatedTelemetryExporters checks if there are several prometheus exporters or several OTLP
+// exporters sending to the same collector. Exporters of different kinds are not duplicates
+func hasDuplicatedTelemetryExporters(s *Service) bool {
+	otel, ok := s.Components["telemetry/opentelemetry"]
+	if !ok || len(otel) < 7 {
+		return false
+	}
+	return otel[4] > 1 || otel[6] > 0
+}
+
+func hasDeprecatedInstana(s *Service) bool {
+	_, ok := s.Components["telemetry/instana"]
+	return ok
+}
+
+// hasDeprecatedGanalytics checks if the service uses telemetry/ganalytics. When withOTEL is true it
+// only applies if telemetry/opentelemetry is also configured, and otherwise only when it is not
+func hasDeprecatedGanalytics(withOTEL bool) func(*Service) bool {
+	return func(s *Service) bool {
+		if _, ok := s.Components["telemetry/ganalytics"]; !ok {
+			return false
+		}
+		_, ok := s.Components["telemetry/opentelemetry"]
+		return ok == withOTEL
+	}
+}
+
+// hasDeprecatedOpenCensus checks if the service uses telemetry/opencensus without any of the known
+// exporters, as each known exporter is reported by hasDeprecatedOpenCensusExporter
+func hasDeprecatedOpenCensus(s *Service) bool {
+	v, ok := s.Components[opencensus.Namespace]
+	return ok && (len(v) == 0 || v[0] == 0)
+}
+
+func hasDeprecatedOpenCensusExporter(exporter int) func(*Service) bool {
+	return func(s *Service) bool {
+		v, ok := s.Components[opencensus.Namespace]
+		return ok && len(v) > 0 && hasBit(v[0], exporter)
+	}
+}
+
+func hasDeprecatedTLSPrivPubKey(s *Service) bool {
+	return hasBit(s.Details[0], ServiceTLSPrivPubKey)
+}
+
+func hasNoLogging(s *Service) bool {
+	_, ok1 := s.Components[gologging.Namespace]
+	_, ok2 := s.Components[gelf.Namespace]
+	_, ok3 := s.Components[logstash.Namespace]
+	return !ok1 && !ok2 && !ok3
+}
+
+func hasDebugLogging(s *Service) bool {
+	v, ok := s.Components[gologging.Namespace]
+	return ok && len(v) > 0 && hasBit(v[0], LoggingLevelDebug) && hasBit(v[0], LoggingStdout)
+}
+
+func hasRestfulDisabled(s *Service) bool {
+	return hasBit(s.Details[0], ServiceDisableStrictREST)
+}
+
+// hasDebugEnabled checks the service debug_endpoint flag, so it applies even when no endpoint
+// under /__debug/ is declared
+func hasDebugEnabled(s *Service) bool {
+	return hasBit(s.Details[0], ServiceDebug)
+}
+
+// hasEchoEnabled checks the service echo_endpoint flag, so it applies even when no endpoint
+// under /__echo/ is declared
 func hasEchoEnabled(s *Service) bool {
 	return hasBit(s.Details[0], ServiceEcho)
 }
 
-func hasEndpointWithoutBackends(s *Service) bool {
-	for _, e := range s.Endpoints {
-		if len(e.Backends) == 0 {
+func hasCacheTTLBeyondTimeout(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if len(e.Details) < 8 || e.Details[7] == 0 || e.Details[7] >= e.Details[3] {
+			return false
+		}
+		for _, b := range e.Backends {
+			if _, ok := b.Components[httpcache.Namespace]; ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// setsCacheControl checks if the components add or replace the Cache-Control header with the
+// modifier/response-headers component
+func setsCacheControl(c Component) bool {
+	v, ok := c["modifier/response-headers"]
+	return ok && len(v) > 0 && hasBit(v[0], BitResponseHeadersCacheControl)
+}
+
+func hasStaticWithoutCacheHeaders(s *Service) []Location {
+	if setsCacheControl(s.Components) {
+		return nil
+	}
+
+	var res []Location
+	if _, ok := s.Components["server/static-filesystem"]; ok {
+		res = append(res, serviceLocation())
+	}
+	for i, e := range s.Endpoints {
+		if (len(e.Details) > 7 && e.Details[7] > 0) || setsCacheControl(e.Components) {
+			continue
+		}
+		for j, b := range e.Backends {
+			if _, ok := b.Components["backend/static-filesystem"]; ok {
+				res = append(res, backendLocation(i, j))
+			}
+		}
+	}
+	return res
+}
+
+// isAuthenticated checks if any component authenticates the requests, including the legacy JWT
+// validator namespace
+func isAuthenticated(c Component) bool {
+	if _, ok := c[jose.ValidatorNamespace]; ok {
+		return true
+	}
+	for ns := range c {
+		if strings.HasPrefix(ns, "auth/") {
 			return true
 		}
 	}
 	return false
 }
 
+// hasUncacheableGET locates the GET and HEAD endpoints without a cache_ttl, a Cache-Control header
+// set by modifier/response-headers or a cache component in the endpoint or its backends. Only the
+// candidates for caching are reported: the no-op endpoints proxy the response of the backend as
+// is and the authenticated ones serve user data, so both are skipped
+func hasUncacheableGET(s *Service) []Location {
+	if setsCacheControl(s.Components) {
+		return nil
+	}
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if len(e.Details) < 8 || e.Details[7] > 0 || setsCacheControl(e.Components) {
+			return false
+		}
+		if hasBit(e.Details[0], EncodingNOOP) || isAuthenticated(e.Components) {
+			return false
+		}
+		flags := e.Details[6]
+		if hasBit(flags, EndpointMethodWildcard) || hasBit(flags, EndpointCacheComponent) {
+			return false
+		}
+		return hasBit(flags, MethodGET) || hasBit(flags, MethodHEAD)
+	})
+}
+
+// hasStaticDirectoryListing locates the static-filesystem components listing the content of the
+// directories, as it discloses the name of every served file
+func hasStaticDirectoryListing(s *Service) []Location {
+	isListing := func(c Component, k string) bool {
+		v, ok := c[k]
+		return ok && len(v) > 0 && hasBit(v[0], StaticFilesystemDirectoryListing)
+	}
+
+	var res []Location
+	if isListing(s.Components, "server/static-filesystem") {
+		res = append(res, serviceLocation())
+	}
+	for i, e := range s.Endpoints {
+		for j, b := range e.Backends {
+			if isListing(b.Components, "backend/static-filesystem") {
+				res = append(res, backendLocation(i, j))
+			}
+		}
+	}
+	return res
+}
+
+func hasAuthEndpointCached(s *Service) []Location {
+	var res []Location
+	for i, e := range s.Endpoints {
+		if _, ok := e.Components[jose.ValidatorNamespace]; !ok {
+			continue
+		}
+		for j, b := range e.Backends {
+			if _, ok := b.Components[httpcache.Namespace]; !ok {
+				continue
+			}
+			if len(b.Details) == 0 || !hasBit(b.Details[0], BackendURLWithJWTClaim) {
+				res = append(res, backendLocation(i, j))
+			}
+		}
+	}
+	return res
+}
+
+func hasEndpointWithoutBackends(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		return len(e.Backends) == 0
+	})
+}
+
 func hasASingleBackendPerEndpoint(s *Service) bool {
 	for _, e := range s.Endpoints {
 		if len(e.Backends) > 1 {
@@ -403,6 +1400,29 @@ func hasASingleBackendPerEndpoint(s *Service) bool {
 	return true
 }
 
+func hasSingleFailureDomain(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		return len(e.Backends) > 1 && len(e.Details) > 8 && e.Details[8] == 1
+	})
+}
+
+// hasTooManyBackends locates the endpoints aggregating more than max backends, hurting their
+// latency and reliability
+func hasTooManyBackends(max int) func(*Service) []Location {
+	return func(s *Service) []Location {
+		var res []Location
+		for i, e := range s.Endpoints {
+			if len(e.Backends) <= max {
+				continue
+			}
+			l := endpointLocation(i)
+			l.Detail = fmt.Sprintf("backends: %d", len(e.Backends))
+			res = append(res, l)
+		}
+		return res
+	}
+}
+
 func hasAllEndpointsAsNoop(s *Service) bool {
 	for _, e := range s.Endpoints {
 		if !hasBit(e.Details[0], EncodingNOOP) {
@@ -412,25 +1432,160 @@ func hasAllEndpointsAsNoop(s *Service) bool {
 	return true
 }
 
+// hasEncodingMismatch locates the endpoints decoding their response (any output_encoding but
+// no-op) with backends using the no-op encoding, as their raw responses can not be merged nor
+// encoded into the declared output. The opposite case is not reported, as the backends of the
+// no-op endpoints are forced to the no-op encoding when the configuration is loaded
+func hasEncodingMismatch(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if len(e.Details) == 0 || hasBit(e.Details[0], EncodingNOOP) {
+			return false
+		}
+		for _, b := range e.Backends {
+			if len(b.Details) > 0 && hasBit(b.Details[0], EncodingNOOP) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// noopIgnoredComponents are the endpoint components manipulating the response body, which is not
+// processed by the endpoints using the no-op encoding
+var noopIgnoredComponents = []string{
+	"modifier/response-body",
+	"modifier/jmespath",
+	"validation/response-json-schema",
+}
+
+func hasNoopWithTransformations(s *Service) []Location {
+	return endpointsMatching(s, func(e Endpoint) bool {
+		if len(e.Details) == 0 || !hasBit(e.Details[0], EncodingNOOP) {
+			return false
+		}
+		for _, k := range noopIgnoredComponents {
+			if _, ok := e.Components[k]; ok {
+				return true
+			}
+		}
+		// flatmap_filter
+		p, ok := e.Components[proxy.Namespace]
+		return ok && len(p) > 0 && hasBit(p[0], 1)
+	})
+}
+
 func hasSequentialStart(s *Service) bool {
 	return hasBit(s.Details[0], ServiceSequentialStart) && len(s.Agents) >= 10
 }
 
+func hasAsyncAgentWithoutBackend(s *Service) []Location {
+	var res []Location
+	for i, a := range s.Agents {
+		if len(a.Backends) == 0 {
+			res = append(res, agentLocation(i))
+		}
+	}
+	return res
+}
+
+// hasUnboundedAgentConsumer locates the async agents with more than maxWorkers workers or without
+// a consumer max_rate, as a burst of messages can overwhelm their backends. The location includes
+// the number of workers
+func hasUnboundedAgentConsumer(maxWorkers int) func(*Service) []Location {
+	return func(s *Service) []Location {
+		var res []Location
+		for i, a := range s.Agents {
+			if len(a.Details) < 5 || (a.Details[1] <= maxWorkers && a.Details[4] > 0) {
+				continue
+			}
+			l := agentLocation(i)
+			l.Detail = fmt.Sprintf("workers: %d", a.Details[1])
+			if a.Details[4] <= 0 {
+				l.Detail += ", no max_rate"
+			}
+			res = append(res, l)
+		}
+		return res
+	}
+}
+
+func hasAgentWithoutBackoff(s *Service) []Location {
+	var res []Location
+	for i, a := range s.Agents {
+		if len(a.Details) > 5 && !hasBit(a.Details[5], AgentBackoffStrategy) {
+			res = append(res, agentLocation(i))
+		}
+	}
+	return res
+}
+
+// hasDuplicatedAgentName locates the async agents named as a previous one. The location details
+// the agent declared first
+func hasDuplicatedAgentName(s *Service) []Location {
+	var res []Location
+	for i, a := range s.Agents {
+		if len(a.Details) < 7 || a.Details[6] == 0 {
+			continue
+		}
+		l := agentLocation(i)
+		l.Detail = fmt.Sprintf("duplicates async_agent[%d]", a.Details[6]-1)
+		res = append(res, l)
+	}
+	return res
+}
+
+func hasNonIdempotentAgentPipeline(s *Service) []Location {
+	var res []Location
+	for i, a := range s.Agents {
+		for j, b := range a.Backends {
+			if _, ok := b.Components["backend/pubsub/publisher"]; ok {
+				res = append(res, agentBackendLocation(i, j))
+				continue
+			}
+			if p, ok := b.Components["backend/amqp/producer"]; ok && (len(p) == 0 || !hasBit(p[0], 0)) {
+				res = append(res, agentBackendLocation(i, j))
+			}
+		}
+	}
+	return res
+}
+
 func hasEmptyGRPCServer(s *Service) bool {
 	return len(s.Components["grpc"]) > 0 && s.Components["grpc"][0] == 0
 }
 
-func hasUnlimitedCache(s *Service) bool {
-	for _, e := range s.Endpoints {
-		for _, b := range e.Backends {
+func hasGRPCReflection(s *Service) bool {
+	v := s.Components["grpc"]
+	return len(v) > 1 && hasBit(v[1], GRPCServerReflection)
+}
+
+func hasUnlimitedCache(s *Service) []Location {
+	var res []Location
+	for i, e := range s.Endpoints {
+		for j, b := range e.Backends {
 			cache, ok := b.Components[httpcache.Namespace]
 			if !ok {
 				continue
 			}
 			if !hasBit(cache[0], 1) || !hasBit(cache[0], 2) {
-				return true
+				res = append(res, backendLocation(i, j))
 			}
 		}
 	}
-	return false
+	return res
+}
+
+// hasAutoAckConsumer locates the AMQP consumer backends with auto_ack. The messages are acknowledged
+// even when processing them fails, so the broker never redelivers them nor routes them to a
+// dead-letter exchange. The pub/sub backends have no equivalent option, so they are not checked
+func hasAutoAckConsumer(s *Service) []Location {
+	var res []Location
+	for i, e := range s.Endpoints {
+		for j, b := range e.Backends {
+			if len(b.Details) > 0 && hasBit(b.Details[0], BackendAMQPAutoAck) {
+				res = append(res, backendLocation(i, j))
+			}
+		}
+	}
+	return res
 }
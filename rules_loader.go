@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleDefinition is the declarative description of a rule. Match is an expression with the format
+//
+//	<operator> <namespace> at <level>
+//
+// where the operator is "present" or "absent" and the level is "service", "endpoint", "backend"
+// (the backends of the endpoints and the async agents) or "agent". The rules at the service level
+// apply when the namespace is present in (or absent from) the service extra_config. The rules at
+// the other levels report every element with (or without) the namespace in its extra_config.
+// Examples:
+//
+//	present auth/api-keys at service
+//	absent qos/circuit-breaker at backend
+type RuleDefinition struct {
+	ID       string `json:"id" yaml:"id"`
+	Severity string `json:"severity" yaml:"severity"`
+	Message  string `json:"message" yaml:"message"`
+	Match    string `json:"match" yaml:"match"`
+}
+
+// LoadRules parses a JSON or YAML list of RuleDefinition and returns the rules they describe, ready
+// to be added to AuditOptions.Rules. It fails if any definition is incomplete, reuses the id of
+// another rule or contains an invalid match expression
+func LoadRules(r io.Reader) ([]Rule, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []RuleDefinition
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		err = json.Unmarshal(b, &defs)
+	} else {
+		err = yaml.Unmarshal(b, &defs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding the rule definitions: %w", err)
+	}
+
+	ids := map[string]struct{}{}
+	for _, r := range builtinRules() {
+		ids[r.Recommendation.Rule] = struct{}{}
+	}
+
+	res := make([]Rule, 0, len(defs))
+	for i, def := range defs {
+		if def.ID == "" || def.Message == "" {
+			return nil, fmt.Errorf("rule #%d: the id and the message are required", i)
+		}
+		if _, ok := ids[def.ID]; ok {
+			return nil, fmt.Errorf("rule %s: the id is already in use", def.ID)
+		}
+		ids[def.ID] = struct{}{}
+
+		if _, ok := severityRank[strings.ToUpper(def.Severity)]; !ok {
+			return nil, fmt.Errorf("rule %s: unknown severity %q", def.ID, def.Severity)
+		}
+		def.Severity = strings.ToUpper(def.Severity)
+
+		rule, err := newDeclarativeRule(def)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", def.ID, err)
+		}
+		res = append(res, rule)
+	}
+	return res, nil
+}
+
+func newDeclarativeRule(def RuleDefinition) (Rule, error) {
+	terms := strings.Fields(def.Match)
+	if len(terms) != 4 || terms[2] != "at" {
+		return Rule{}, fmt.Errorf("malformed match expression %q, expected '<operator> <namespace> at <level>'", def.Match)
+	}
+
+	var present bool
+	switch terms[0] {
+	case "present":
+		present = true
+	case "absent":
+	default:
+		return Rule{}, fmt.Errorf("unknown operator %q", terms[0])
+	}
+
+	namespace := terms[1]
+	matches := func(c Component) bool {
+		_, ok := c[namespace]
+		return ok == present
+	}
+
+	switch terms[3] {
+	case "service":
+		return NewRule(def.ID, def.Severity, def.Message, func(s *Service) bool {
+			return matches(s.Components)
+		}), nil
+	case "endpoint":
+		return NewLocatedRule(def.ID, def.Severity, def.Message, func(s *Service) []Location {
+			return endpointsMatching(s, func(e Endpoint) bool { return matches(e.Components) })
+		}), nil
+	case "backend":
+		return NewLocatedRule(def.ID, def.Severity, def.Message, func(s *Service) []Location {
+			var res []Location
+			for i, e := range s.Endpoints {
+				for j, b := range e.Backends {
+					if matches(b.Components) {
+						res = append(res, backendLocation(i, j))
+					}
+				}
+			}
+			for i, a := range s.Agents {
+				for j, b := range a.Backends {
+					if matches(b.Components) {
+						res = append(res, agentBackendLocation(i, j))
+					}
+				}
+			}
+			return res
+		}), nil
+	case "agent":
+		return NewLocatedRule(def.ID, def.Severity, def.Message, func(s *Service) []Location {
+			var res []Location
+			for i, a := range s.Agents {
+				if matches(a.Components) {
+					res = append(res, agentLocation(i))
+				}
+			}
+			return res
+		}), nil
+	}
+	return Rule{}, fmt.Errorf("unknown level %q", terms[3])
+}
@@ -0,0 +1,179 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/luraproject/lura/v2/config"
+)
+
+func TestLoadRules(t *testing.T) {
+	for name, src := range map[string]string{
+		"json": `[
+	{"id": "org.1", "severity": "HIGH", "message": "Use API keys.", "match": "absent auth/api-keys at service"},
+	{"id": "org.2", "severity": "LOW", "message": "Cache the backends.", "match": "absent qos/http-cache at backend"}
+]`,
+		"yaml": `
+- id: org.1
+  severity: HIGH
+  message: Use API keys.
+  match: absent auth/api-keys at service
+- id: org.2
+  severity: low
+  message: Cache the backends.
+  match: absent qos/http-cache at backend
+`,
+	} {
+		rules, err := LoadRules(strings.NewReader(src))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if len(rules) != 2 {
+			t.Errorf("%s: unexpected number of rules: %d", name, len(rules))
+			continue
+		}
+		if !reflect.DeepEqual(rules[0].Recommendation, Recommendation{Rule: "org.1", Severity: SeverityHigh, Message: "Use API keys."}) {
+			t.Errorf("%s: unexpected recommendation: %+v", name, rules[0].Recommendation)
+		}
+		if rules[1].Recommendation.Severity != SeverityLow {
+			t.Errorf("%s: unexpected severity: %s", name, rules[1].Recommendation.Severity)
+		}
+		if rules[0].Locate != nil || rules[1].Locate == nil {
+			t.Errorf("%s: unexpected locators", name)
+		}
+	}
+}
+
+func TestLoadRules_levels(t *testing.T) {
+	s := &Service{
+		Components: Component{"auth/api-keys": []int{}},
+		Endpoints: []Endpoint{
+			{Components: Component{"foo": []int{}}, Backends: []Backend{{}, {Components: Component{"foo": []int{}}}}},
+			{},
+		},
+		Agents: []Agent{{Backends: []Backend{{}}}, {Components: Component{"foo": []int{}}}},
+	}
+
+	for match, want := range map[string][]Location{
+		"present foo at endpoint": {endpointLocation(0)},
+		"absent foo at endpoint":  {endpointLocation(1)},
+		"present foo at backend":  {backendLocation(0, 1)},
+		"absent foo at backend":   {backendLocation(0, 0), agentBackendLocation(0, 0)},
+		"present foo at agent":    {agentLocation(1)},
+		"absent foo at agent":     {agentLocation(0)},
+	} {
+		r, err := newDeclarativeRule(RuleDefinition{ID: "x", Severity: SeverityLow, Message: "x", Match: match})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", match, err)
+			continue
+		}
+		if ls := r.Locate(s); !reflect.DeepEqual(ls, want) {
+			t.Errorf("%s: unexpected locations: %v", match, ls)
+		}
+	}
+
+	for match, want := range map[string]bool{
+		"present auth/api-keys at service": true,
+		"absent auth/api-keys at service":  false,
+		"present foo at service":           false,
+		"absent foo at service":            true,
+	} {
+		r, err := newDeclarativeRule(RuleDefinition{ID: "x", Severity: SeverityLow, Message: "x", Match: match})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", match, err)
+			continue
+		}
+		if res := r.Evaluate(s); res != want {
+			t.Errorf("%s: unexpected result. have: %v, want: %v", match, res, want)
+		}
+	}
+}
+
+func TestLoadRules_errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src string
+		err string
+	}{
+		"unknown operator": {
+			src: `[{"id": "org.1", "severity": "LOW", "message": "x", "match": "missing foo at service"}]`,
+			err: `rule org.1: unknown operator "missing"`,
+		},
+		"unknown level": {
+			src: `[{"id": "org.1", "severity": "LOW", "message": "x", "match": "present foo at router"}]`,
+			err: `rule org.1: unknown level "router"`,
+		},
+		"malformed expression": {
+			src: `[{"id": "org.1", "severity": "LOW", "message": "x", "match": "present foo"}]`,
+			err: `rule org.1: malformed match expression "present foo", expected '<operator> <namespace> at <level>'`,
+		},
+		"unknown severity": {
+			src: `[{"id": "org.1", "severity": "URGENT", "message": "x", "match": "present foo at service"}]`,
+			err: `rule org.1: unknown severity "URGENT"`,
+		},
+		"built-in id": {
+			src: `[{"id": "1.1.1", "severity": "LOW", "message": "x", "match": "present foo at service"}]`,
+			err: `rule 1.1.1: the id is already in use`,
+		},
+		"duplicated id": {
+			src: `[{"id": "org.1", "severity": "LOW", "message": "x", "match": "present foo at service"}, {"id": "org.1", "severity": "LOW", "message": "y", "match": "present bar at service"}]`,
+			err: `rule org.1: the id is already in use`,
+		},
+		"missing message": {
+			src: `[{"id": "org.1", "severity": "LOW", "match": "present foo at service"}]`,
+			err: `rule #0: the id and the message are required`,
+		},
+	} {
+		_, err := LoadRules(strings.NewReader(tc.src))
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+
+	if _, err := LoadRules(strings.NewReader(`[{"id": `)); err == nil {
+		t.Error("expecting a decoding error")
+	}
+}
+
+func TestAuditWith_rules(t *testing.T) {
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	cfg.Normalize()
+
+	rules, err := LoadRules(strings.NewReader(`[{"id": "org.1", "severity": "LOW", "message": "Use API keys.", "match": "present auth/api-keys at service"}]`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result, err := AuditWith(&cfg, AuditOptions{Ignore: []string{"org.2"}, Severities: []string{SeverityLow}, Rules: rules})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !reflect.DeepEqual(result.Stats.UnknownIgnored, []string{"org.2"}) {
+		t.Errorf("unexpected unknown ignored rules: %v", result.Stats.UnknownIgnored)
+	}
+	last := result.Recommendations[len(result.Recommendations)-1]
+	if last.Rule != "org.1" {
+		t.Errorf("custom rule not reported: %+v", last)
+	}
+
+	result, err = AuditWith(&cfg, AuditOptions{Ignore: []string{"org.1"}, Severities: []string{SeverityLow}, Rules: rules})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(result.Stats.UnknownIgnored) > 0 {
+		t.Errorf("unexpected unknown ignored rules: %v", result.Stats.UnknownIgnored)
+	}
+	for _, r := range result.Recommendations {
+		if r.Rule == "org.1" {
+			t.Error("ignored custom rule reported")
+		}
+	}
+}
@@ -1,21 +1,32 @@
 package audit
 
 import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
 	botdetector "github.com/krakendio/krakend-botdetector/v2/krakend"
 	cb "github.com/krakendio/krakend-circuitbreaker/v2/gobreaker"
 	cors "github.com/krakendio/krakend-cors/v2"
 	gelf "github.com/krakendio/krakend-gelf/v2"
 	gologging "github.com/krakendio/krakend-gologging/v2"
+	httpcache "github.com/krakendio/krakend-httpcache/v2"
 	httpsecure "github.com/krakendio/krakend-httpsecure/v2"
 	jose "github.com/krakendio/krakend-jose/v2"
 	logstash "github.com/krakendio/krakend-logstash/v2"
+	luaproxy "github.com/krakendio/krakend-lua/v2/proxy"
+	luarouter "github.com/krakendio/krakend-lua/v2/router"
 	metrics "github.com/krakendio/krakend-metrics/v2"
 	opencensus "github.com/krakendio/krakend-opencensus/v2"
 	ratelimitProxy "github.com/krakendio/krakend-ratelimit/v3/proxy"
 	ratelimit "github.com/krakendio/krakend-ratelimit/v3/router"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/proxy"
 	router "github.com/luraproject/lura/v2/router/gin"
+	client "github.com/luraproject/lura/v2/transport/http/client/plugin"
 	server "github.com/luraproject/lura/v2/transport/http/server/plugin"
 )
 
@@ -86,6 +97,49 @@ func Test_hasTLSDisabled(t *testing.T) {
 	}
 }
 
+func Test_hasWeakTLS(t *testing.T) {
+	for _, c := range []*config.TLS{
+		nil,
+		{},
+		{MinVersion: "TLS12", MaxVersion: "TLS13"},
+		{MinVersion: "TLS10", IsDisabled: true},
+		{MinVersion: "TLS12", CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}},
+	} {
+		if s, _ := Parse(&config.ServiceConfig{TLS: c}); hasWeakTLS(&s) {
+			t.Errorf("false positive: %+v", c)
+		}
+	}
+
+	for _, c := range []*config.TLS{
+		{MinVersion: "TLS10"},
+		{MinVersion: "TLS11", MaxVersion: "TLS13"},
+		{MinVersion: "SSL3.0"},
+		{CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_RSA_WITH_RC4_128_SHA}},
+		{MinVersion: "TLS12", CipherSuites: []uint16{tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA}},
+	} {
+		if s, _ := Parse(&config.ServiceConfig{TLS: c}); !hasWeakTLS(&s) {
+			t.Errorf("false negative: %+v", c)
+		}
+	}
+}
+
+func Test_hasUnenforcedMTLS(t *testing.T) {
+	for _, c := range []*config.TLS{
+		nil,
+		{},
+		{CaCerts: []string{"ca.pem"}, EnableMTLS: true},
+		{EnableMTLS: true},
+	} {
+		if s, _ := Parse(&config.ServiceConfig{TLS: c}); hasUnenforcedMTLS(&s) {
+			t.Errorf("false positive: %+v", c)
+		}
+	}
+
+	if s, _ := Parse(&config.ServiceConfig{TLS: &config.TLS{CaCerts: []string{"ca.pem"}}}); !hasUnenforcedMTLS(&s) {
+		t.Error("false negative")
+	}
+}
+
 func Test_hasNoHTTPSecure(t *testing.T) {
 	if hasNoHTTPSecure(&Service{Components: Component{httpsecure.Namespace: []int{}}}) {
 		t.Error("false positive")
@@ -116,6 +170,70 @@ func Test_hasNoCORS(t *testing.T) {
 	}
 }
 
+func Test_hasLargeIdleConnectionPool(t *testing.T) {
+	for _, d := range [][]int{{0}, {0, 0, 250}, {0, 1000, 1000}} {
+		if hasLargeIdleConnectionPool(DefaultThresholds.MaxIdleConnections)(&Service{Details: d}) {
+			t.Errorf("false positive: %v", d)
+		}
+	}
+	for _, d := range [][]int{{0, 5000, 0}, {0, 0, 1001}} {
+		if !hasLargeIdleConnectionPool(DefaultThresholds.MaxIdleConnections)(&Service{Details: d}) {
+			t.Errorf("false negative: %v", d)
+		}
+	}
+}
+
+func Test_hasDuplicatedNamespace(t *testing.T) {
+	src := `{
+	"version": 3,
+	"extra_config": {"security/cors": {}, "security/cors": {}},
+	"endpoints": [
+		{"endpoint": "/a", "backend": [{"host": ["http://a"], "url_pattern": "/a"}]},
+		{
+			"endpoint": "/b",
+			"extra_config": {"auth/validator": {"alg": "RS256", "extra_config": {"x": 1, "x": 2}}, "auth/validator": {}},
+			"backend": [{"host": ["http://b"], "url_pattern": "/b", "extra_config": {"qos/http-cache": {}, "qos/http-cache": {}}}]
+		}
+	],
+	"async_agent": [
+		{"name": "agent", "extra_config": {"async/amqp": {}, "async/amqp": {}}, "backend": [{"host": ["http://c"], "url_pattern": "/c", "extra_config": {"a": 1, "a": 2}}]}
+	]
+}`
+	s, _, err := parseJSON("test.json", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Location{serviceLocation(), endpointLocation(1), backendLocation(1, 0), agentLocation(0), agentBackendLocation(0, 0)}
+	if ls := hasDuplicatedNamespace(&s); !reflect.DeepEqual(ls, want) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+
+	s, _ = Parse(&config.ServiceConfig{ExtraConfig: config.ExtraConfig{"security/cors": map[string]interface{}{}}})
+	if ls := hasDuplicatedNamespace(&s); len(ls) > 0 {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+
+	// the keys duplicated inside the config of a component are not namespaces
+	src = `{
+	"version": 3,
+	"extra_config": {"telemetry/logging": {"extra_config": {"a": 1, "a": 2}}},
+	"endpoints": [
+		{
+			"endpoint": "/a",
+			"extra_config": {"auth/validator": {"alg": "RS256", "extra_config": {"x": 1, "x": 2}}},
+			"backend": [{"host": ["http://a"], "url_pattern": "/a", "extra_config": {"qos/http-cache": {"extra_config": {"y": 1, "y": 2}}}}]
+		}
+	]
+}`
+	s, _, err = parseJSON("test.json", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ls := hasDuplicatedNamespace(&s); len(ls) > 0 {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
 func Test_hasBotdetectorDisabled(t *testing.T) {
 	if hasBotdetectorDisabled(&Service{Components: Component{botdetector.Namespace: []int{1 << 17}}}) {
 		t.Error("false positive")
@@ -126,6 +244,21 @@ func Test_hasBotdetectorDisabled(t *testing.T) {
 	}
 }
 
+func Test_hasIneffectiveBotdetector(t *testing.T) {
+	for _, v := range [][]int{{0, 1, 0, 0}, {0, 0, 2, 0}, {0, 0, 0, 100}} {
+		if hasIneffectiveBotdetector(&Service{Components: Component{botdetector.Namespace: v}}) {
+			t.Errorf("false positive: %v", v)
+		}
+	}
+	if hasIneffectiveBotdetector(&Service{Components: Component{}}) {
+		t.Error("false positive")
+	}
+
+	if !hasIneffectiveBotdetector(&Service{Components: Component{botdetector.Namespace: []int{3, 0, 0, 0}}}) {
+		t.Error("false negative")
+	}
+}
+
 func Test_hasNoRatelimit(t *testing.T) {
 	if hasNoRatelimit(&Service{Components: Component{ratelimit.Namespace: []int{1 << 17}}}) {
 		t.Error("false positive")
@@ -145,6 +278,25 @@ func Test_hasNoRatelimit(t *testing.T) {
 	}
 }
 
+func Test_endpointsWithoutRatelimit(t *testing.T) {
+	if ls := endpointsWithoutRatelimit(&Service{
+		Components: Component{ratelimit.Namespace: []int{1 << 17}},
+		Endpoints:  []Endpoint{{}, {}},
+	}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+
+	ls := endpointsWithoutRatelimit(&Service{Endpoints: []Endpoint{
+		{Components: Component{ratelimit.Namespace: []int{1 << 17}}},
+		{},
+		{Backends: []Backend{{Components: Component{ratelimitProxy.Namespace: []int{1 << 17}}}}},
+		{Backends: []Backend{{}}},
+	}})
+	if !reflect.DeepEqual(ls, []Location{endpointLocation(1), endpointLocation(3)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
 func Test_hasNoCB(t *testing.T) {
 	if hasNoCB(&Service{Endpoints: []Endpoint{{Components: Component{cb.Namespace: []int{1 << 17}}}}}) {
 		t.Error("false positive")
@@ -158,13 +310,253 @@ func Test_hasNoCB(t *testing.T) {
 	}
 }
 
-func Test_hasTimeoutBiggerThan(t *testing.T) {
-	if hasTimeoutBiggerThan(1000)(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 100}}}}) {
+func Test_endpointsWithoutCB(t *testing.T) {
+	ls := endpointsWithoutCB(&Service{Endpoints: []Endpoint{
+		{Components: Component{cb.Namespace: []int{1 << 17}}},
+		{},
+		{Backends: []Backend{{Components: Component{cb.Namespace: []int{1 << 17}}}}},
+	}})
+	if !reflect.DeepEqual(ls, []Location{endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasTimeoutBetween(t *testing.T) {
+	if len(hasTimeoutBetween(1000, 5000)(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 100}}}})) > 0 {
+		t.Error("false positive")
+	}
+	if len(hasTimeoutBetween(1000, 5000)(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 10000}}}})) > 0 {
 		t.Error("false positive")
 	}
 
-	if !hasTimeoutBiggerThan(1000)(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 10000}}}}) {
-		t.Error("false negative")
+	ls := hasTimeoutBetween(1000, 0)(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 1000}},
+		{Details: []int{0, 0, 0, 90000}},
+	}})
+	want := endpointLocation(1)
+	want.Detail = "timeout: 1m30s"
+	if !reflect.DeepEqual(ls, []Location{want}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasBackendQueryStringWildcard(t *testing.T) {
+	wildcard := Backend{Details: []int{1 << BackendQueryStringWildcard}}
+	if ls := hasBackendQueryStringWildcard(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0}, Backends: []Backend{{Details: []int{0}}}},
+		{Details: []int{0, 0, 0, 0, 1 << BitEndpointQueryStringWildcard}, Backends: []Backend{wildcard}},
+	}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	ls := hasBackendQueryStringWildcard(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0}, Backends: []Backend{{Details: []int{0}}, wildcard}},
+	}})
+	if !reflect.DeepEqual(ls, []Location{backendLocation(0, 1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasBackendHeadersWildcard(t *testing.T) {
+	s, _ := Parse(&config.ServiceConfig{Endpoints: []*config.EndpointConfig{
+		{Endpoint: "/a", Backend: []*config.Backend{{HeadersToPass: []string{"X-Foo"}}}},
+		{Endpoint: "/b", HeadersToPass: []string{"*"}, Backend: []*config.Backend{{HeadersToPass: []string{"*"}}}},
+		{Endpoint: "/c", HeadersToPass: []string{"X-Foo"}, Backend: []*config.Backend{{}, {HeadersToPass: []string{"X-Foo", "*"}}}},
+	}})
+	if ls := hasBackendHeadersWildcard(&s); !reflect.DeepEqual(ls, []Location{backendLocation(2, 1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+	if ls := hasHeadersWildcard(&s); !reflect.DeepEqual(ls, []Location{endpointLocation(1)}) {
+		t.Errorf("unexpected endpoint locations: %v", ls)
+	}
+}
+
+func Test_hasHighConcurrentCalls(t *testing.T) {
+	if ls := hasHighConcurrentCalls(DefaultThresholds.MaxConcurrentCalls)(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 1, 1}},
+		{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 1, 3}},
+	}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	ls := hasHighConcurrentCalls(DefaultThresholds.MaxConcurrentCalls)(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 1, 1}},
+		{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 1, 5}},
+	}})
+	want := endpointLocation(1)
+	want.Detail = "concurrent_calls: 5"
+	if !reflect.DeepEqual(ls, []Location{want}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasWriteEndpointWithGETBackend(t *testing.T) {
+	get := []Backend{{Details: []int{1 << BackendMethodGET}}}
+	if ls := hasWriteEndpointWithGETBackend(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0, 0, 1 << MethodGET}, Backends: get},
+		{Details: []int{0, 0, 0, 0, 0, 0, 1 << MethodPOST}, Backends: []Backend{{Details: []int{0}}}},
+		{Details: []int{0, 0, 0, 0, 0, 0, 1 << MethodPUT}, Backends: append(get, Backend{Details: []int{0}})},
+		{Details: []int{0, 0, 0, 0, 0, 0, 1 << MethodPATCH}, Backends: get, Components: Component{"modifier/body-generator": []int{}}},
+		{Details: []int{0, 0, 0, 0, 0, 0, 1 << MethodPOST}, Backends: []Backend{{Details: []int{1 << BackendMethodGET}, Components: Component{"modifier/body-generator": []int{}}}}},
+	}}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+
+	ls := hasWriteEndpointWithGETBackend(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0, 0, 1 << MethodGET}, Backends: get},
+		{Details: []int{0, 0, 0, 0, 0, 0, 1 << MethodPOST}, Backends: get},
+	}})
+	if !reflect.DeepEqual(ls, []Location{endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasTooManyBackends(t *testing.T) {
+	if ls := hasTooManyBackends(DefaultThresholds.MaxBackendsPerEndpoint)(&Service{Endpoints: []Endpoint{
+		{Backends: make([]Backend, 1)},
+		{Backends: make([]Backend, 5)},
+	}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	ls := hasTooManyBackends(DefaultThresholds.MaxBackendsPerEndpoint)(&Service{Endpoints: []Endpoint{
+		{Backends: make([]Backend, 5)},
+		{Backends: make([]Backend, 7)},
+	}})
+	want := endpointLocation(1)
+	want.Detail = "backends: 7"
+	if !reflect.DeepEqual(ls, []Location{want}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasNoopWithTransformations(t *testing.T) {
+	noop := 1 << EncodingNOOP
+	if ls := hasNoopWithTransformations(&Service{Endpoints: []Endpoint{
+		{Details: []int{noop}},
+		{Details: []int{noop}, Components: Component{proxy.Namespace: []int{1}}},
+		{Details: []int{1 << EncodingJSON}, Components: Component{"modifier/response-body": []int{1}}},
+	}}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+
+	ls := hasNoopWithTransformations(&Service{Endpoints: []Endpoint{
+		{Details: []int{noop}, Components: Component{"modifier/response-body": []int{1}}},
+		{Details: []int{noop}, Components: Component{proxy.Namespace: []int{1 << 1}}},
+		{Details: []int{noop}},
+	}})
+	if !reflect.DeepEqual(ls, []Location{endpointLocation(0), endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasOverlappingEndpoints(t *testing.T) {
+	if ls := hasOverlappingEndpoints(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 0}},
+		{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 1, 1}},
+	}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	ls := hasOverlappingEndpoints(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 0}},
+		{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1}},
+	}})
+	want := endpointLocation(1)
+	want.Detail = "overlaps endpoints[0]"
+	if !reflect.DeepEqual(ls, []Location{want}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasAggregationWithoutTimeout(t *testing.T) {
+	backends := `[{"host": ["http://a"], "url_pattern": "/a"}, {"host": ["http://b"], "url_pattern": "/b"}]`
+	for _, tc := range []struct {
+		name string
+		src  string
+		want []Location
+	}{
+		{
+			name: "implicit",
+			src:  `{"version": 3, "endpoints": [{"endpoint": "/a", "backend": [{"host": ["http://a"], "url_pattern": "/a"}]}, {"endpoint": "/b", "timeout": "1s", "backend": ` + backends + `}, {"endpoint": "/c", "backend": ` + backends + `}]}`,
+			want: []Location{endpointLocation(2)},
+		},
+		{
+			name: "service timeout",
+			src:  `{"version": 3, "timeout": "3s", "endpoints": [{"endpoint": "/c", "backend": ` + backends + `}]}`,
+		},
+	} {
+		p := filepath.Join(t.TempDir(), "krakend.json")
+		if err := os.WriteFile(p, []byte(tc.src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := config.NewParser().Parse(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the parser sets a default timeout, so the decoded configuration never triggers the rule
+		s, _ := Parse(&cfg)
+		if ls := hasAggregationWithoutTimeout(&s); len(ls) > 0 {
+			t.Errorf("%s: unexpected locations for the decoded configuration: %v", tc.name, ls)
+		}
+
+		s, _, err = parseJSON(p, []byte(tc.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ls := hasAggregationWithoutTimeout(&s); !reflect.DeepEqual(ls, tc.want) {
+			t.Errorf("%s: unexpected locations: %v", tc.name, ls)
+		}
+	}
+}
+
+func Test_hasDeprecatedOpenCensus(t *testing.T) {
+	if hasDeprecatedOpenCensus(&Service{Components: Component{}}) {
+		t.Error("false positive without opencensus")
+	}
+	if hasDeprecatedOpenCensus(&Service{Components: Component{opencensus.Namespace: []int{1 << OpenCensusDatadog, 100, 0}}}) {
+		t.Error("false positive with a known exporter")
+	}
+	if !hasDeprecatedOpenCensus(&Service{Components: Component{opencensus.Namespace: []int{0, 100, 0}}}) {
+		t.Error("opencensus without known exporters not detected")
+	}
+}
+
+func Test_hasDeprecatedOpenCensusExporter(t *testing.T) {
+	s := &Service{Components: Component{opencensus.Namespace: []int{1<<OpenCensusZipkin | 1<<OpenCensusDatadog, 100, 0}}}
+	for _, exporter := range []int{OpenCensusZipkin, OpenCensusDatadog} {
+		if !hasDeprecatedOpenCensusExporter(exporter)(s) {
+			t.Errorf("exporter %d not detected", exporter)
+		}
+	}
+	for _, exporter := range []int{OpenCensusLogger, OpenCensusJaeger, OpenCensusPrometheus, OpenCensusOCAgent} {
+		if hasDeprecatedOpenCensusExporter(exporter)(s) {
+			t.Errorf("false positive for exporter %d", exporter)
+		}
+	}
+	if hasDeprecatedOpenCensusExporter(OpenCensusZipkin)(&Service{Components: Component{}}) {
+		t.Error("false positive without opencensus")
+	}
+}
+
+func Test_hasDeprecatedGanalytics(t *testing.T) {
+	ga := Component{"telemetry/ganalytics": []int{}}
+	both := Component{"telemetry/ganalytics": []int{}, "telemetry/opentelemetry": []int{-1, -1, 1, 1, 0, 0, 0}}
+	for i, tc := range []struct {
+		c        Component
+		withOTEL bool
+		want     bool
+	}{
+		{c: Component{}, withOTEL: false, want: false},
+		{c: Component{}, withOTEL: true, want: false},
+		{c: ga, withOTEL: false, want: true},
+		{c: ga, withOTEL: true, want: false},
+		{c: both, withOTEL: false, want: false},
+		{c: both, withOTEL: true, want: true},
+	} {
+		if res := hasDeprecatedGanalytics(tc.withOTEL)(&Service{Components: tc.c}); res != tc.want {
+			t.Errorf("#%d: unexpected result. have: %v, want: %v", i, res, tc.want)
+		}
 	}
 }
 
@@ -190,6 +582,116 @@ func Test_hasNoMetrics(t *testing.T) {
 	}
 }
 
+func Test_hasNoOpenTelemetry(t *testing.T) {
+	if hasNoOpenTelemetry(&Service{Components: Component{"telemetry/opentelemetry": []int{0, 0, 1, 1, 0}}}) {
+		t.Error("false positive")
+	}
+	if hasNoOpenTelemetry(&Service{Components: Component{
+		"telemetry/opentelemetry": []int{0, 0, 0, 1, 0},
+		metrics.Namespace:         []int{1},
+	}}) {
+		t.Error("false positive")
+	}
+
+	if !hasNoOpenTelemetry(&Service{Components: Component{}}) {
+		t.Error("false negative")
+	}
+	if !hasNoOpenTelemetry(&Service{Components: Component{metrics.Namespace: []int{1}}}) {
+		t.Error("false negative")
+	}
+}
+
+func Test_hasFullTraceSampling(t *testing.T) {
+	for _, c := range []Component{
+		{},
+		{"telemetry/opentelemetry": []int{0, 10, 1, 1, 0}},
+		{"telemetry/opentelemetry": []int{0, -1, 1, 0, 0}},
+		{opencensus.Namespace: []int{2, 50}},
+	} {
+		if hasFullTraceSampling(&Service{Components: c}) {
+			t.Errorf("false positive: %v", c)
+		}
+	}
+
+	for _, c := range []Component{
+		{"telemetry/opentelemetry": []int{0, 100, 1, 1, 0}},
+		{"telemetry/opentelemetry": []int{0, -1, 1, 1, 0}},
+		{opencensus.Namespace: []int{2, 100}},
+		{opencensus.Namespace: []int{2, -1}},
+	} {
+		if !hasFullTraceSampling(&Service{Components: c}) {
+			t.Errorf("false negative: %v", c)
+		}
+	}
+
+	defer func(v int) { defaultTraceSampleRate = v }(defaultTraceSampleRate)
+	defaultTraceSampleRate = 10
+	if hasFullTraceSampling(&Service{Components: Component{opencensus.Namespace: []int{2, -1}}}) {
+		t.Error("false positive with a custom default rate")
+	}
+}
+
+func Test_hasInsecureTelemetryExporter(t *testing.T) {
+	for _, c := range []Component{
+		{},
+		{"telemetry/opentelemetry": []int{0, 10, 1, 1, 0}},
+		{"telemetry/opentelemetry": []int{0, 10, 1, 1, 0, 0}},
+		{opencensus.Namespace: []int{2, 50}},
+		{opencensus.Namespace: []int{2, 50, 0}},
+	} {
+		if hasInsecureTelemetryExporter(&Service{Components: c}) {
+			t.Errorf("false positive: %v", c)
+		}
+	}
+
+	for _, c := range []Component{
+		{"telemetry/opentelemetry": []int{0, 10, 1, 1, 0, 1}},
+		{opencensus.Namespace: []int{2, 50, 1}},
+	} {
+		if !hasInsecureTelemetryExporter(&Service{Components: c}) {
+			t.Errorf("false negative: %v", c)
+		}
+	}
+}
+
+func Test_hasDuplicatedTelemetryExporters(t *testing.T) {
+	for _, c := range []Component{
+		{},
+		{"telemetry/opentelemetry": []int{0, 10, 2, 2, 0}},
+		{"telemetry/opentelemetry": []int{0, 10, 2, 2, 1, 0, 0}},
+	} {
+		if hasDuplicatedTelemetryExporters(&Service{Components: c}) {
+			t.Errorf("false positive: %v", c)
+		}
+	}
+
+	for _, c := range []Component{
+		{"telemetry/opentelemetry": []int{0, 10, 2, 2, 2, 0, 0}},
+		{"telemetry/opentelemetry": []int{0, 10, 2, 2, 1, 0, 1}},
+	} {
+		if !hasDuplicatedTelemetryExporters(&Service{Components: c}) {
+			t.Errorf("false negative: %v", c)
+		}
+	}
+}
+
+func Test_hasDebugLogging(t *testing.T) {
+	for _, c := range []Component{
+		{},
+		{gologging.Namespace: []int{}},
+		{gologging.Namespace: []int{1 << LoggingStdout}},
+		{gologging.Namespace: []int{1<<LoggingLevelDebug | 1<<LoggingSyslog}},
+	} {
+		if hasDebugLogging(&Service{Components: c}) {
+			t.Errorf("false positive: %v", c)
+		}
+	}
+
+	if !hasDebugLogging(&Service{Components: Component{gologging.Namespace: []int{1<<LoggingLevelDebug | 1<<LoggingStdout}}}) {
+		t.Error("false negative")
+	}
+}
+
 func Test_hasSeveralTelemetryComponents(t *testing.T) {
 	if hasSeveralTelemetryComponents(&Service{Components: Component{opencensus.Namespace: []int{1 << 17}}}) {
 		t.Error("false positive")
@@ -268,14 +770,28 @@ func Test_hasDebugEnabled(t *testing.T) {
 	if !hasDebugEnabled(&Service{Details: []int{1 << ServiceDebug}}) {
 		t.Error("false negative")
 	}
+
+	if s, _ := Parse(&config.ServiceConfig{Debug: true}); !hasDebugEnabled(&s) {
+		t.Error("false negative without a declared /__debug/ endpoint")
+	}
+}
+
+func Test_hasEchoEnabled(t *testing.T) {
+	if s, _ := Parse(&config.ServiceConfig{}); hasEchoEnabled(&s) {
+		t.Error("false positive")
+	}
+
+	if s, _ := Parse(&config.ServiceConfig{Echo: true}); !hasEchoEnabled(&s) {
+		t.Error("false negative without a declared /__echo/ endpoint")
+	}
 }
 
 func Test_hasEndpointWithoutBackends(t *testing.T) {
-	if hasEndpointWithoutBackends(&Service{Endpoints: []Endpoint{{Backends: []Backend{{}}}}}) {
+	if len(hasEndpointWithoutBackends(&Service{Endpoints: []Endpoint{{Backends: []Backend{{}}}}})) > 0 {
 		t.Error("false positive")
 	}
 
-	if !hasEndpointWithoutBackends(&Service{Endpoints: []Endpoint{{}}}) {
+	if len(hasEndpointWithoutBackends(&Service{Endpoints: []Endpoint{{}}})) == 0 {
 		t.Error("false negative")
 	}
 }
@@ -299,3 +815,930 @@ func Test_hasAllEndpointsAsNoop(t *testing.T) {
 		t.Error("false negative")
 	}
 }
+
+func Test_hasMissingContentTypeForward(t *testing.T) {
+	post := addBit(0, MethodPOST)
+	if len(hasMissingContentTypeForward(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 1, 0, 0, 0, addBit(post, EndpointInputHeaderContentType)}}}})) > 0 {
+		t.Error("false positive")
+	}
+	if len(hasMissingContentTypeForward(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 0, 0, 0, post}}}})) > 0 {
+		t.Error("false positive")
+	}
+	if len(hasMissingContentTypeForward(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 1, 0, 1 << BitEndpointHeaderStringWildcard, 0, post}}}})) > 0 {
+		t.Error("false positive")
+	}
+	if len(hasMissingContentTypeForward(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 1, 0, 0, 0, addBit(0, MethodGET)}}}})) > 0 {
+		t.Error("false positive")
+	}
+
+	if len(hasMissingContentTypeForward(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 1, 0, 0, 0, post}}}})) == 0 {
+		t.Error("false negative")
+	}
+}
+
+func Test_hasCacheTTLBeyondTimeout(t *testing.T) {
+	cached := []Backend{{Components: Component{httpcache.Namespace: []int{0}}}}
+	if ls := hasCacheTTLBeyondTimeout(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 3000, 0, 0, 0, 0}, Backends: cached}}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+	if ls := hasCacheTTLBeyondTimeout(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 3000, 0, 0, 0, 60000}, Backends: cached}}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+	if ls := hasCacheTTLBeyondTimeout(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 3000, 0, 0, 0, 1000}, Backends: []Backend{{}}}}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	if ls := hasCacheTTLBeyondTimeout(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 3000, 0, 0, 0, 1000}, Backends: cached}}}); len(ls) != 1 {
+		t.Error("false negative")
+	}
+}
+
+func Test_hasWildcardMethod(t *testing.T) {
+	if ls := hasWildcardMethod(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 0, 0, 0, addBit(0, MethodGET)}}}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	ls := hasWildcardMethod(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0, 0, addBit(0, MethodPOST)}},
+		{Details: []int{0, 0, 0, 0, 0, 0, addBit(addBit(0, MethodOther), EndpointMethodWildcard)}},
+	}})
+	if !reflect.DeepEqual(ls, []Location{endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasAuthEndpointCached(t *testing.T) {
+	validator := Component{jose.ValidatorNamespace: []int{}}
+	cache := Component{httpcache.Namespace: []int{0}}
+	if ls := hasAuthEndpointCached(&Service{Endpoints: []Endpoint{{Backends: []Backend{{Details: []int{0}, Components: cache}}}}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+	if ls := hasAuthEndpointCached(&Service{Endpoints: []Endpoint{{Components: validator, Backends: []Backend{{Details: []int{0}}}}}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+	if ls := hasAuthEndpointCached(&Service{Endpoints: []Endpoint{{Components: validator, Backends: []Backend{{Details: []int{1 << BackendURLWithJWTClaim}, Components: cache}}}}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	ls := hasAuthEndpointCached(&Service{Endpoints: []Endpoint{{Components: validator, Backends: []Backend{{Details: []int{0}}, {Details: []int{0}, Components: cache}}}}})
+	if !reflect.DeepEqual(ls, []Location{backendLocation(0, 1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasIPRatelimitWithoutPrivacy(t *testing.T) {
+	if ls := hasIPRatelimitWithoutPrivacy(&Service{
+		Components: Component{ratelimit.Namespace: []int{1 + 8}},
+		Endpoints:  []Endpoint{{Components: Component{ratelimit.Namespace: []int{1}}}},
+	}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	ls := hasIPRatelimitWithoutPrivacy(&Service{
+		Components: Component{ratelimit.Namespace: []int{2 + 4}},
+		Endpoints: []Endpoint{
+			{Components: Component{ratelimit.Namespace: []int{1}}},
+			{Components: Component{ratelimit.Namespace: []int{4}}},
+		},
+	})
+	if !reflect.DeepEqual(ls, []Location{serviceLocation(), endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasIneffectiveRatelimit(t *testing.T) {
+	if ls := hasIneffectiveRatelimit(&Service{
+		Components: Component{ratelimit.Namespace: []int{1, 10, 0}},
+		Endpoints:  []Endpoint{{Components: Component{ratelimit.Namespace: []int{2 + 4, 0, 5}}}, {}},
+	}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	ls := hasIneffectiveRatelimit(&Service{
+		Components: Component{ratelimit.Namespace: []int{0, 0, 0}},
+		Endpoints: []Endpoint{
+			{Components: Component{ratelimit.Namespace: []int{1, 10, 0}}},
+			{Components: Component{ratelimit.Namespace: []int{4, 0, 0}}},
+		},
+	})
+	if !reflect.DeepEqual(ls, []Location{serviceLocation(), endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasRouterRatelimitWithoutClientLimit(t *testing.T) {
+	if ls := hasRouterRatelimitWithoutClientLimit(&Service{
+		Components: Component{ratelimit.Namespace: []int{1 + 2 + 4, 10, 1}},
+		Endpoints: []Endpoint{
+			{Components: Component{ratelimit.Namespace: []int{1 + 8, 10, 0}}},
+			{Components: Component{ratelimit.Namespace: []int{0, 0, 0}}},
+			{},
+		},
+	}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	ls := hasRouterRatelimitWithoutClientLimit(&Service{
+		Endpoints: []Endpoint{
+			{Components: Component{ratelimit.Namespace: []int{1 + 2, 10, 1}}},
+			{Components: Component{ratelimit.Namespace: []int{1, 10, 0}}},
+		},
+	})
+	if !reflect.DeepEqual(ls, []Location{endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasMisconfiguredCB(t *testing.T) {
+	maxErrors := 1 << CircuitBreakerMaxErrors
+	if ls := hasMisconfiguredCB(DefaultThresholds.MaxCircuitBreakerTimeout)(&Service{
+		Endpoints: []Endpoint{{
+			Components: Component{cb.Namespace: []int{5, 60, 10, maxErrors}},
+			Backends: []Backend{
+				{Components: Component{cb.Namespace: []int{1, 60, 600, maxErrors}}},
+				{Components: Component{cb.Namespace: []int{0, 60, 10, 0}}},
+				{},
+			},
+		}},
+	}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	ls := hasMisconfiguredCB(DefaultThresholds.MaxCircuitBreakerTimeout)(&Service{
+		Endpoints: []Endpoint{{
+			Backends: []Backend{
+				{Components: Component{cb.Namespace: []int{5, 60, 10, maxErrors}}},
+				{Components: Component{cb.Namespace: []int{0, 60, 10, maxErrors}}},
+			},
+		}},
+		Agents: []Agent{{Backends: []Backend{{Components: Component{cb.Namespace: []int{0, 60, 3600, 0}}}}}},
+	})
+	if !reflect.DeepEqual(ls, []Location{backendLocation(0, 1), agentBackendLocation(0, 0)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasRetryWithoutBackoff(t *testing.T) {
+	if ls := hasRetryWithoutBackoff(DefaultThresholds.MaxLinearRetries)(&Service{
+		Endpoints: []Endpoint{{Backends: []Backend{{Details: []int{0}}, {}}}},
+	}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	s := &Service{
+		Endpoints: []Endpoint{
+			{Backends: []Backend{{Details: []int{0}}}},
+			{Backends: []Backend{{Details: []int{0}}, {Details: []int{1 << BackendRetryWithoutBackoff}}}},
+			{Backends: []Backend{{Details: []int{0, 5}}, {Details: []int{0, 6}}}},
+		},
+	}
+	if ls := hasRetryWithoutBackoff(DefaultThresholds.MaxLinearRetries)(s); !reflect.DeepEqual(ls, []Location{backendLocation(1, 1), backendLocation(2, 1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+	if ls := hasRetryWithoutBackoff(10)(s); !reflect.DeepEqual(ls, []Location{backendLocation(1, 1)}) {
+		t.Errorf("unexpected locations with a higher threshold: %v", ls)
+	}
+}
+
+func Test_hasInsecureCORSCredentials(t *testing.T) {
+	if hasInsecureCORSCredentials(&Service{Components: Component{}}) {
+		t.Error("false positive")
+	}
+	if hasInsecureCORSCredentials(&Service{Components: Component{cors.Namespace: []int{1 << CORSAllowOriginsWildcard}}}) {
+		t.Error("false positive")
+	}
+	if hasInsecureCORSCredentials(&Service{Components: Component{cors.Namespace: []int{1 << CORSAllowCredentials}}}) {
+		t.Error("false positive")
+	}
+
+	if !hasInsecureCORSCredentials(&Service{Components: Component{cors.Namespace: []int{1<<CORSAllowOriginsWildcard | 1<<CORSAllowCredentials}}}) {
+		t.Error("false negative")
+	}
+}
+
+func Test_hasSingleFailureDomain(t *testing.T) {
+	if ls := hasSingleFailureDomain(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 1}, Backends: []Backend{{}}}}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+	if ls := hasSingleFailureDomain(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 2}, Backends: []Backend{{}, {}}}}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+	if ls := hasSingleFailureDomain(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 0}, Backends: []Backend{{}, {}}}}}); len(ls) > 0 {
+		t.Error("false positive")
+	}
+
+	if ls := hasSingleFailureDomain(&Service{Endpoints: []Endpoint{{Details: []int{0, 0, 0, 0, 0, 0, 0, 0, 1}, Backends: []Backend{{}, {}}}}}); len(ls) != 1 {
+		t.Error("false negative")
+	}
+}
+
+func Test_hasWeakJWTAlg(t *testing.T) {
+	s := &Service{Endpoints: []Endpoint{
+		{Components: Component{jose.ValidatorNamespace: []int{0}}},
+		{Components: Component{jose.ValidatorNamespace: []int{1 << ValidatorAlgNone}}},
+		{Components: Component{jose.ValidatorNamespace: []int{1 << ValidatorAlgHMAC}}},
+		{Components: Component{jose.ValidatorNamespace: []int{}}},
+		{},
+	}}
+
+	if ls := hasWeakJWTAlg(ValidatorAlgNone)(s); !reflect.DeepEqual(ls, []Location{endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+	if ls := hasWeakJWTAlg(ValidatorAlgHMAC)(s); !reflect.DeepEqual(ls, []Location{endpointLocation(2)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasStaticWithoutCacheHeaders(t *testing.T) {
+	static := []Backend{{Components: Component{"backend/static-filesystem": []int{}}}}
+	if ls := hasStaticWithoutCacheHeaders(&Service{
+		Components: Component{"server/static-filesystem": []int{}, "modifier/response-headers": []int{1<<BitResponseHeadersAdd | 1<<BitResponseHeadersCacheControl}},
+		Endpoints:  []Endpoint{{Details: []int{0, 0, 0, 0, 0, 0, 0, 0}, Backends: static}},
+	}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+	if ls := hasStaticWithoutCacheHeaders(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0, 0, 0, 1000}, Backends: static},
+		{Details: []int{0, 0, 0, 0, 0, 0, 0, 0}, Backends: static, Components: Component{"modifier/response-headers": []int{1<<BitResponseHeadersReplace | 1<<BitResponseHeadersCacheControl}}},
+		{Details: []int{0, 0, 0, 0, 0, 0, 0, 0}, Backends: []Backend{{}}},
+	}}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+
+	ls := hasStaticWithoutCacheHeaders(&Service{
+		Components: Component{"server/static-filesystem": []int{}, "modifier/response-headers": []int{1 << BitResponseHeadersAdd}},
+		Endpoints:  []Endpoint{{Details: []int{0, 0, 0, 0, 0, 0, 0, 0}, Backends: static}},
+	})
+	if !reflect.DeepEqual(ls, []Location{serviceLocation(), backendLocation(0, 0)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasUncacheableGET(t *testing.T) {
+	get := 1 << MethodGET
+	if ls := hasUncacheableGET(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0, 0, get, 1000}},
+		{Details: []int{0, 0, 0, 0, 0, 0, get | 1<<EndpointCacheComponent, 0}},
+		{Details: []int{0, 0, 0, 0, 0, 0, get, 0}, Components: Component{"modifier/response-headers": []int{1 << BitResponseHeadersCacheControl}}},
+		{Details: []int{0, 0, 0, 0, 0, 0, 1 << MethodPOST, 0}},
+		{Details: []int{0, 0, 0, 0, 0, 0, 1<<MethodOther | 1<<EndpointMethodWildcard, 0}},
+		{Details: []int{1 << EncodingNOOP, 0, 0, 0, 0, 0, get, 0}},
+		{Details: []int{0, 0, 0, 0, 0, 0, get, 0}, Components: Component{jose.ValidatorNamespace: []int{}}},
+		{Details: []int{0, 0, 0, 0, 0, 0, get, 0}, Components: Component{"auth/api-keys": []int{}}},
+	}}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+	if ls := hasUncacheableGET(&Service{
+		Components: Component{"modifier/response-headers": []int{1 << BitResponseHeadersCacheControl}},
+		Endpoints:  []Endpoint{{Details: []int{0, 0, 0, 0, 0, 0, get, 0}}},
+	}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+
+	ls := hasUncacheableGET(&Service{Endpoints: []Endpoint{
+		{Details: []int{0, 0, 0, 0, 0, 0, get, 1000}},
+		{Details: []int{0, 0, 0, 0, 0, 0, get, 0}},
+		{Details: []int{0, 0, 0, 0, 0, 0, 1 << MethodHEAD, 0}},
+	}})
+	if !reflect.DeepEqual(ls, []Location{endpointLocation(1), endpointLocation(2)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasMixedVirtualhost(t *testing.T) {
+	plugin := []int{1 << parseServerPlugin("virtualhost")}
+	for i, c := range []Component{
+		{},
+		{server.Namespace: plugin},
+		{"server/virtualhost": []int{}},
+		{server.Namespace: []int{1 << parseServerPlugin("wildcard")}, "server/virtualhost": []int{}},
+	} {
+		if hasMixedVirtualhost(&Service{Components: c}) {
+			t.Errorf("#%d: false positive", i)
+		}
+	}
+	if !hasMixedVirtualhost(&Service{Components: Component{server.Namespace: plugin, "server/virtualhost": []int{}}}) {
+		t.Error("mixed virtualhost not detected")
+	}
+}
+
+func Test_hasDeprecatedClientPlugin(t *testing.T) {
+	noRedirect := hasDeprecatedClientPlugin("no-redirect", "http-client-no-redirect")
+	for _, name := range []string{"no-redirect", "http-client-no-redirect"} {
+		ls := noRedirect(&Service{Endpoints: []Endpoint{
+			{Components: Component{client.Namespace: []int{parseClientPlugin("http-logger")}}},
+			{Components: Component{client.Namespace: []int{parseClientPlugin(name)}}},
+		}})
+		if !reflect.DeepEqual(ls, []Location{endpointLocation(1)}) {
+			t.Errorf("%s: unexpected locations: %v", name, ls)
+		}
+	}
+
+	if ls := noRedirect(&Service{Endpoints: []Endpoint{
+		{Components: Component{client.Namespace: []int{parseClientPlugin("unknown")}}},
+		{Components: Component{client.Namespace: []int{}}},
+		{},
+	}}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+}
+
+func Test_hasStaticDirectoryListing(t *testing.T) {
+	if ls := hasStaticDirectoryListing(&Service{
+		Components: Component{"server/static-filesystem": []int{0}},
+		Endpoints: []Endpoint{{Backends: []Backend{
+			{Components: Component{"backend/static-filesystem": []int{}}},
+			{Components: Component{"backend/static-filesystem": []int{0}}},
+		}}},
+	}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+
+	listing := []int{1 << StaticFilesystemDirectoryListing}
+	ls := hasStaticDirectoryListing(&Service{
+		Components: Component{"server/static-filesystem": listing},
+		Endpoints: []Endpoint{{Backends: []Backend{
+			{Components: Component{"backend/static-filesystem": []int{0}}},
+			{Components: Component{"backend/static-filesystem": listing}},
+		}}},
+	})
+	if !reflect.DeepEqual(ls, []Location{serviceLocation(), backendLocation(0, 1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasInsecureJWKURL(t *testing.T) {
+	s := &Service{Endpoints: []Endpoint{
+		{Components: Component{jose.ValidatorNamespace: []int{0}}},
+		{Components: Component{jose.ValidatorNamespace: []int{1 << ValidatorJWKURLInsecure}}},
+		{Components: Component{jose.ValidatorNamespace: []int{1 << ValidatorJWKURLInsecureLoopback}}},
+	}}
+
+	if ls := hasInsecureJWKURL(false)(s); !reflect.DeepEqual(ls, []Location{endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+	if ls := hasInsecureJWKURL(true)(s); !reflect.DeepEqual(ls, []Location{endpointLocation(2)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasAsyncAgentWithoutBackend(t *testing.T) {
+	if ls := hasAsyncAgentWithoutBackend(&Service{Agents: []Agent{{Backends: make([]Backend, 1)}}}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+
+	ls := hasAsyncAgentWithoutBackend(&Service{Agents: []Agent{{Backends: make([]Backend, 1)}, {}}})
+	if !reflect.DeepEqual(ls, []Location{agentLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasUnboundedAgentConsumer(t *testing.T) {
+	if ls := hasUnboundedAgentConsumer(DefaultThresholds.MaxAgentWorkers)(&Service{Agents: []Agent{
+		{Details: []int{0, 1, 0, 0, 10}},
+		{Details: []int{0, 20, 0, 0, 1}},
+		{Details: []int{0, 100, 0, 0}},
+	}}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+
+	ls := hasUnboundedAgentConsumer(DefaultThresholds.MaxAgentWorkers)(&Service{Agents: []Agent{
+		{Details: []int{0, 1, 0, 0, 10}},
+		{Details: []int{0, 50, 0, 0, 10}},
+		{Details: []int{0, 2, 0, 0, 0}},
+	}})
+	want := []Location{agentLocation(1), agentLocation(2)}
+	want[0].Detail = "workers: 50"
+	want[1].Detail = "workers: 2, no max_rate"
+	if !reflect.DeepEqual(ls, want) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasAgentWithoutBackoff(t *testing.T) {
+	if ls := hasAgentWithoutBackoff(&Service{Agents: []Agent{
+		{Details: []int{0, 1, 0, 0, 0, 1 << AgentBackoffStrategy}},
+		{Details: []int{0, 1, 0, 0}},
+	}}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+
+	ls := hasAgentWithoutBackoff(&Service{Agents: []Agent{
+		{Details: []int{0, 1, 0, 0, 0, 1 << AgentBackoffStrategy}},
+		{Details: []int{0, 1, 0, 0, 0, 0}},
+	}})
+	if !reflect.DeepEqual(ls, []Location{agentLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasDuplicatedAgentName(t *testing.T) {
+	if ls := hasDuplicatedAgentName(&Service{Agents: []Agent{
+		{Details: []int{0, 1, 0, 0, 0, 0, 0}},
+		{Details: []int{0, 1, 0, 0}},
+	}}); len(ls) > 0 {
+		t.Errorf("false positive: %v", ls)
+	}
+
+	ls := hasDuplicatedAgentName(&Service{Agents: []Agent{
+		{Details: []int{0, 1, 0, 0, 0, 0, 0}},
+		{Details: []int{0, 1, 0, 0, 0, 0, 1}},
+	}})
+	want := agentLocation(1)
+	want.Detail = "duplicates async_agent[0]"
+	if !reflect.DeepEqual(ls, []Location{want}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasNonIdempotentAgentPipeline(t *testing.T) {
+	ls := hasNonIdempotentAgentPipeline(&Service{Agents: []Agent{
+		{Backends: []Backend{
+			{Components: Component{"backend/amqp/producer": []int{1}}},
+			{Components: Component{"backend/amqp/producer": []int{0}}},
+			{},
+		}},
+		{Backends: []Backend{
+			{Components: Component{"backend/pubsub/publisher": []int{}}},
+		}},
+	}})
+	if !reflect.DeepEqual(ls, []Location{agentBackendLocation(0, 1), agentBackendLocation(1, 0)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasJWTWithoutClaimsCheck(t *testing.T) {
+	ls := hasJWTWithoutClaimsCheck(&Service{Endpoints: []Endpoint{
+		{},
+		{Components: Component{jose.ValidatorNamespace: []int{1 << ValidatorIssuer}}},
+		{Components: Component{jose.ValidatorNamespace: []int{1 << ValidatorAudience}}},
+		{Components: Component{jose.ValidatorNamespace: []int{1 << ValidatorAlgHMAC}}},
+	}})
+	if !reflect.DeepEqual(ls, []Location{endpointLocation(3)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasSecurityHTTPDevMode(t *testing.T) {
+	if hasSecurityHTTPDevMode(&Service{Components: Component{}}) {
+		t.Error("false positive")
+	}
+	if hasSecurityHTTPDevMode(&Service{Components: Component{httpsecure.Namespace: []int{0}}}) {
+		t.Error("false positive")
+	}
+
+	if !hasSecurityHTTPDevMode(&Service{Components: Component{httpsecure.Namespace: []int{1 << HTTPSecureIsDevelopment}}}) {
+		t.Error("false negative")
+	}
+}
+
+func Test_hasHTTPSecureWithoutHSTS(t *testing.T) {
+	for _, c := range []Component{
+		{},
+		{httpsecure.Namespace: []int{}},
+		{httpsecure.Namespace: []int{0, 31536000}},
+	} {
+		if hasHTTPSecureWithoutHSTS(&Service{Components: c}) {
+			t.Errorf("false positive: %v", c)
+		}
+	}
+
+	if !hasHTTPSecureWithoutHSTS(&Service{Components: Component{httpsecure.Namespace: []int{0, 0}}}) {
+		t.Error("false negative")
+	}
+}
+
+func Test_hasUncachedJWK(t *testing.T) {
+	ls := hasUncachedJWK(&Service{Endpoints: []Endpoint{
+		{},
+		{Components: Component{jose.ValidatorNamespace: []int{0}}},
+		{Components: Component{jose.ValidatorNamespace: []int{1<<ValidatorJWKRemote | 1<<ValidatorJWKCache}}},
+		{Components: Component{jose.ValidatorNamespace: []int{1 << ValidatorJWKRemote}}},
+	}})
+	if !reflect.DeepEqual(ls, []Location{endpointLocation(3)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasPermissiveCORSHeaders(t *testing.T) {
+	for _, c := range []Component{
+		{},
+		{cors.Namespace: []int{}},
+		{cors.Namespace: []int{1 << CORSAllowCredentials}},
+		{cors.Namespace: []int{1 << CORSAllowHeadersEmpty}},
+	} {
+		if hasPermissiveCORSHeaders(&Service{Components: c}) {
+			t.Errorf("false positive: %v", c)
+		}
+	}
+
+	for _, c := range []Component{
+		{cors.Namespace: []int{1 << CORSAllowHeadersWildcard}},
+		{cors.Namespace: []int{1<<CORSAllowHeadersEmpty | 1<<CORSAllowCredentials}},
+	} {
+		if !hasPermissiveCORSHeaders(&Service{Components: c}) {
+			t.Errorf("false negative: %v", c)
+		}
+	}
+}
+
+func Test_hasNoCORSMaxAge(t *testing.T) {
+	for _, c := range []Component{
+		{},
+		{cors.Namespace: []int{}},
+		{cors.Namespace: []int{0, 43200}},
+	} {
+		if hasNoCORSMaxAge(&Service{Components: c}) {
+			t.Errorf("false positive: %v", c)
+		}
+	}
+
+	if !hasNoCORSMaxAge(&Service{Components: Component{cors.Namespace: []int{0, 0}}}) {
+		t.Error("false negative")
+	}
+}
+
+func Test_hasPermissiveCORSMethods(t *testing.T) {
+	if hasPermissiveCORSMethods(&Service{Components: Component{}}) {
+		t.Error("false positive")
+	}
+	if hasPermissiveCORSMethods(&Service{Components: Component{cors.Namespace: []int{1 << CORSAllowCredentials}}}) {
+		t.Error("false positive")
+	}
+
+	if !hasPermissiveCORSMethods(&Service{Components: Component{cors.Namespace: []int{1 << CORSAllowMethodsPermissive}}}) {
+		t.Error("false negative")
+	}
+}
+
+func Test_hasHardcodedSecrets(t *testing.T) {
+	secret := Backend{Details: []int{1 << BackendHardcodedSecret}}
+	ls := hasHardcodedSecrets(&Service{
+		Endpoints: []Endpoint{{Backends: []Backend{{Details: []int{0}}, secret}}},
+		Agents:    []Agent{{Backends: []Backend{secret}}},
+	})
+	if !reflect.DeepEqual(ls, []Location{backendLocation(0, 1), agentBackendLocation(0, 0)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasGRPCReflection(t *testing.T) {
+	for _, c := range []Component{
+		{},
+		{"grpc": []int{1}},
+		{"grpc": []int{1, 0}},
+	} {
+		if hasGRPCReflection(&Service{Components: c}) {
+			t.Errorf("false positive: %v", c)
+		}
+	}
+
+	if !hasGRPCReflection(&Service{Components: Component{"grpc": []int{1, 1 << GRPCServerReflection}}}) {
+		t.Error("false negative")
+	}
+}
+
+func Test_hasLuaOpenLibs(t *testing.T) {
+	ls := hasLuaOpenLibs(&Service{
+		Components: Component{luarouter.Namespace: []int{1<<0 | 1<<2}},
+		Endpoints: []Endpoint{
+			{Components: Component{luaproxy.ProxyNamespace: []int{1}}, Backends: []Backend{{Components: Component{luaproxy.BackendNamespace: []int{1 << 2}}}}},
+			{Components: Component{luarouter.Namespace: []int{1 << 2}}, Backends: []Backend{{}}},
+			{Components: Component{luaproxy.ProxyNamespace: []int{}}},
+		},
+	})
+	if !reflect.DeepEqual(ls, []Location{serviceLocation(), backendLocation(0, 0), endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+
+	if ls := hasLuaOpenLibs(&Service{Endpoints: []Endpoint{{Components: Component{luaproxy.ProxyNamespace: []int{3}}}}}); len(ls) > 0 {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasUnroutableBackendHost(t *testing.T) {
+	unroutable := Backend{Details: []int{1 << BackendUnroutableHost}}
+	ls := hasUnroutableBackendHost(&Service{
+		Endpoints: []Endpoint{
+			{Backends: []Backend{{Details: []int{0}}, unroutable}},
+			{Backends: []Backend{{}}},
+		},
+		Agents: []Agent{{Backends: []Backend{unroutable}}},
+	})
+	if !reflect.DeepEqual(ls, []Location{backendLocation(0, 1), agentBackendLocation(0, 0)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasMisconfiguredDNSSD(t *testing.T) {
+	for _, b := range []*config.Backend{
+		{Host: []string{"example.com"}},
+		{Host: []string{"example.com"}, SD: "static"},
+		{Host: []string{"api.service.consul"}, SD: "dns", HostSanitizationDisabled: true},
+	} {
+		s, _ := Parse(&config.ServiceConfig{Endpoints: []*config.EndpointConfig{{Backend: []*config.Backend{b}}}})
+		if ls := hasMisconfiguredDNSSD(&s); len(ls) > 0 {
+			t.Errorf("false positive: %+v", b)
+		}
+	}
+
+	s, _ := Parse(&config.ServiceConfig{
+		Endpoints:   []*config.EndpointConfig{{Backend: []*config.Backend{{Host: []string{"api.service.consul"}, SD: "dns"}}}},
+		AsyncAgents: []*config.AsyncAgent{{Backend: []*config.Backend{{Host: []string{"api.service.consul"}, SD: "dns"}}}},
+	})
+	if ls := hasMisconfiguredDNSSD(&s); !reflect.DeepEqual(ls, []Location{backendLocation(0, 0), agentBackendLocation(0, 0)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasUnfilteredSensitiveConnector(t *testing.T) {
+	lambda := config.ExtraConfig{"backend/lambda": map[string]interface{}{"function_name": "foo"}}
+	s, _ := Parse(&config.ServiceConfig{Endpoints: []*config.EndpointConfig{
+		{Backend: []*config.Backend{{Host: []string{"http://example.com"}}}},
+		{Backend: []*config.Backend{{ExtraConfig: lambda, AllowList: []string{"id"}}}},
+		{Backend: []*config.Backend{{ExtraConfig: config.ExtraConfig{"backend/amqp/producer": map[string]interface{}{}}}}},
+		{Backend: []*config.Backend{{Host: []string{"http://example.com"}}, {ExtraConfig: lambda}}},
+		{Backend: []*config.Backend{{ExtraConfig: config.ExtraConfig{"backend/pubsub/subscriber": map[string]interface{}{}}, Mapping: map[string]string{"a": "b"}}}},
+		{Backend: []*config.Backend{{ExtraConfig: config.ExtraConfig{"backend/amqp/consumer": map[string]interface{}{}}}}},
+	}})
+	if ls := hasUnfilteredSensitiveConnector(&s); !reflect.DeepEqual(ls, []Location{endpointLocation(3), endpointLocation(5)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasAutoAckConsumer(t *testing.T) {
+	consumer := func(autoAck bool) *config.Backend {
+		return &config.Backend{ExtraConfig: config.ExtraConfig{"backend/amqp/consumer": map[string]interface{}{"name": "queue", "auto_ack": autoAck}}}
+	}
+	s, _ := Parse(&config.ServiceConfig{Endpoints: []*config.EndpointConfig{
+		{Backend: []*config.Backend{consumer(false), {ExtraConfig: config.ExtraConfig{"backend/pubsub/subscriber": map[string]interface{}{}}}}},
+		{Backend: []*config.Backend{{}, consumer(true)}},
+	}})
+	if ls := hasAutoAckConsumer(&s); !reflect.DeepEqual(ls, []Location{backendLocation(1, 1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasNoHealthCheck(t *testing.T) {
+	disabled := config.ExtraConfig{router.Namespace: map[string]interface{}{"disable_health": true}}
+	for _, cfg := range []*config.ServiceConfig{
+		{},
+		{ExtraConfig: config.ExtraConfig{router.Namespace: map[string]interface{}{"health_path": "/ping"}}},
+		{ExtraConfig: disabled, Endpoints: []*config.EndpointConfig{{Endpoint: "/foo"}, {Endpoint: "/healthz/"}}},
+	} {
+		if s, _ := Parse(cfg); hasNoHealthCheck(&s) {
+			t.Errorf("false positive: %+v", cfg)
+		}
+	}
+
+	if s, _ := Parse(&config.ServiceConfig{ExtraConfig: disabled, Endpoints: []*config.EndpointConfig{{Endpoint: "/foo/health"}}}); !hasNoHealthCheck(&s) {
+		t.Error("false negative")
+	}
+}
+
+func Test_hasJWTWithoutAuthorizationHeader(t *testing.T) {
+	validator := config.ExtraConfig{jose.ValidatorNamespace: map[string]interface{}{"alg": "RS256"}}
+	httpBackends := []*config.Backend{{Host: []string{"http://example.com"}, URLPattern: "/foo"}}
+	lambdaBackends := []*config.Backend{{ExtraConfig: config.ExtraConfig{"backend/lambda": map[string]interface{}{"function_name": "foo"}}}}
+	s, _ := Parse(&config.ServiceConfig{Endpoints: []*config.EndpointConfig{
+		{Endpoint: "/a", HeadersToPass: []string{"Content-Type"}, Backend: httpBackends},
+		{Endpoint: "/b", ExtraConfig: validator, Backend: httpBackends},
+		{Endpoint: "/c", ExtraConfig: validator, HeadersToPass: []string{"*"}, Backend: httpBackends},
+		{Endpoint: "/d", ExtraConfig: validator, HeadersToPass: []string{"Content-Type", "authorization"}, Backend: httpBackends},
+		{Endpoint: "/e", ExtraConfig: validator, HeadersToPass: []string{"Content-Type", "X-Request-Id"}, Backend: httpBackends},
+		{Endpoint: "/f", ExtraConfig: validator, HeadersToPass: []string{"Content-Type"}, Backend: lambdaBackends},
+		{Endpoint: "/g", ExtraConfig: validator, HeadersToPass: []string{"Content-Type"}, Backend: append(lambdaBackends, httpBackends...)},
+	}})
+	if ls := hasJWTWithoutAuthorizationHeader(&s); !reflect.DeepEqual(ls, []Location{endpointLocation(4), endpointLocation(6)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasSequentialProxyWithoutErrorDetails(t *testing.T) {
+	sequential := config.ExtraConfig{proxy.Namespace: map[string]interface{}{"sequential": true}}
+	s, _ := Parse(&config.ServiceConfig{Endpoints: []*config.EndpointConfig{
+		{Endpoint: "/a", Backend: []*config.Backend{{}}},
+		{Endpoint: "/b", ExtraConfig: sequential, Backend: []*config.Backend{{}, {}}},
+		{Endpoint: "/c", ExtraConfig: sequential, Backend: []*config.Backend{{}, {ExtraConfig: config.ExtraConfig{"backend/http": map[string]interface{}{"return_error_details": "c"}}}}},
+		{Endpoint: "/d", ExtraConfig: sequential, Backend: []*config.Backend{{ExtraConfig: config.ExtraConfig{"backend/http": map[string]interface{}{"return_error_code": true}}}}},
+		{Endpoint: "/e", ExtraConfig: sequential, Backend: []*config.Backend{{ExtraConfig: config.ExtraConfig{"backend/http": map[string]interface{}{"return_error_details": ""}}}}},
+	}})
+	if ls := hasSequentialProxyWithoutErrorDetails(&s); !reflect.DeepEqual(ls, []Location{endpointLocation(1), endpointLocation(4)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasAggregatedStringBackend(t *testing.T) {
+	s, _ := Parse(&config.ServiceConfig{Endpoints: []*config.EndpointConfig{
+		{Endpoint: "/a", Backend: []*config.Backend{{Encoding: "string"}}},
+		{Endpoint: "/b", Backend: []*config.Backend{{Encoding: "json"}, {Encoding: "string"}}},
+		{Endpoint: "/c", Backend: []*config.Backend{{Encoding: "json"}, {Encoding: "safejson"}}},
+	}})
+	if ls := hasAggregatedStringBackend(&s); !reflect.DeepEqual(ls, []Location{endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasServiceMissingName(t *testing.T) {
+	for _, name := range []string{"", "  "} {
+		if s, _ := Parse(&config.ServiceConfig{Name: name}); !hasServiceMissingName(&s) {
+			t.Errorf("false negative: %q", name)
+		}
+	}
+	if s, _ := Parse(&config.ServiceConfig{Name: "gateway"}); hasServiceMissingName(&s) {
+		t.Error("false positive")
+	}
+}
+
+func Test_hasEncodingMismatch(t *testing.T) {
+	s, _ := Parse(&config.ServiceConfig{Endpoints: []*config.EndpointConfig{
+		{Endpoint: "/a", OutputEncoding: "no-op", Backend: []*config.Backend{{Encoding: "no-op"}}},
+		{Endpoint: "/b", OutputEncoding: "json", Backend: []*config.Backend{{Encoding: "no-op"}}},
+		{Endpoint: "/c", OutputEncoding: "json", Backend: []*config.Backend{{Encoding: "json"}, {Encoding: "xml"}}},
+		{Endpoint: "/d", OutputEncoding: "negotiate", Backend: []*config.Backend{{Encoding: "json"}, {Encoding: "no-op"}}},
+	}})
+	if ls := hasEncodingMismatch(&s); !reflect.DeepEqual(ls, []Location{endpointLocation(1), endpointLocation(3)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasOversizedServiceTimeout(t *testing.T) {
+	rule := hasOversizedServiceTimeout(DefaultThresholds.ServiceTimeoutRatio)
+	for _, s := range []*Service{
+		{Details: []int{0, 0, 0, 0, 0, 60000}},
+		{Details: []int{0, 0, 0, 0, 0, 0}, Endpoints: []Endpoint{{Details: []int{0, 0, 0, 1000}}}},
+		{Details: []int{0, 0, 0, 0, 0, 10000}, Endpoints: []Endpoint{{Details: []int{0, 0, 0, 1000}}, {Details: []int{0, 0, 0, 2000}}}},
+		{Details: []int{0, 0, 0, 0, 0, 60000}, Endpoints: []Endpoint{{Details: []int{0, 0, 0, 1000}}, {Details: []int{0, 0, 0, 60000}}}},
+	} {
+		if ls := rule(s); len(ls) > 0 {
+			t.Errorf("false positive: %v", ls)
+		}
+	}
+
+	ls := rule(&Service{Details: []int{0, 0, 0, 0, 0, 60000}, Endpoints: []Endpoint{{Details: []int{0, 0, 0, 1000}}, {Details: []int{0, 0, 0, 3000}}}})
+	want := serviceLocation()
+	want.Detail = "timeout: 1m0s, longest endpoint timeout: 3s"
+	if !reflect.DeepEqual(ls, []Location{want}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasUnprotectedWriteEndpoint(t *testing.T) {
+	backends := []*config.Backend{{URLPattern: "/foo"}}
+	s, _ := Parse(&config.ServiceConfig{Endpoints: []*config.EndpointConfig{
+		{Endpoint: "/a", Method: "GET", Backend: backends},
+		{Endpoint: "/b", Method: "POST", Backend: backends},
+		{Endpoint: "/c", Method: "PUT", Backend: backends, ExtraConfig: config.ExtraConfig{"auth/validator": map[string]interface{}{}}},
+		{Endpoint: "/d", Method: "DELETE", Backend: backends, ExtraConfig: config.ExtraConfig{jose.ValidatorNamespace: map[string]interface{}{}}},
+		{Endpoint: "/e", Method: "PATCH", Backend: []*config.Backend{{ExtraConfig: config.ExtraConfig{"qos/ratelimit/proxy": map[string]interface{}{}}}}},
+		{Endpoint: "/f", Method: "DELETE", Backend: backends, ExtraConfig: config.ExtraConfig{"modifier/lua-endpoint": map[string]interface{}{}}},
+		{Endpoint: "/g", Method: "POST", Backend: backends, ExtraConfig: config.ExtraConfig{"security/cors": map[string]interface{}{}}},
+		{Endpoint: "/h", Method: "PUT", Backend: []*config.Backend{{ExtraConfig: config.ExtraConfig{"qos/circuit-breaker": map[string]interface{}{}}}}},
+		{Endpoint: "/i", Method: "POST", Backend: backends, ExtraConfig: config.ExtraConfig{"validation/json-schema": map[string]interface{}{}}},
+	}})
+	if ls := hasUnprotectedWriteEndpoint(&s); !reflect.DeepEqual(ls, []Location{endpointLocation(1), endpointLocation(5), endpointLocation(6), endpointLocation(7)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+
+	s.Components = Component{"qos/ratelimit/service": []int{}}
+	if ls := hasUnprotectedWriteEndpoint(&s); len(ls) > 0 {
+		t.Errorf("unexpected locations with a service rate limit: %v", ls)
+	}
+}
+
+func Test_hasMissingServerTimeouts(t *testing.T) {
+	missing := func(detail string) []Location {
+		l := serviceLocation()
+		l.Detail = detail
+		return []Location{l}
+	}
+
+	for _, tc := range []struct {
+		cfg     config.ServiceConfig
+		all     []Location
+		partial []Location
+	}{
+		{cfg: config.ServiceConfig{}, all: missing("missing: read_timeout, write_timeout, idle_timeout")},
+		{cfg: config.ServiceConfig{ReadTimeout: time.Second}, partial: missing("missing: write_timeout, idle_timeout")},
+		{cfg: config.ServiceConfig{ReadTimeout: time.Second, IdleTimeout: time.Second}, partial: missing("missing: write_timeout")},
+		{cfg: config.ServiceConfig{ReadTimeout: time.Second, WriteTimeout: time.Second, IdleTimeout: time.Second}},
+	} {
+		s, _ := Parse(&tc.cfg)
+		if ls := hasMissingServerTimeouts(true)(&s); !reflect.DeepEqual(ls, tc.all) {
+			t.Errorf("unexpected locations of the missing timeouts: %v", ls)
+		}
+		if ls := hasMissingServerTimeouts(false)(&s); !reflect.DeepEqual(ls, tc.partial) {
+			t.Errorf("unexpected locations of the partial timeouts: %v", ls)
+		}
+	}
+}
+
+func Test_hasUnboundedHeaderBytes(t *testing.T) {
+	rule := hasUnboundedHeaderBytes(DefaultThresholds.MaxHeaderBytes)
+	if s, _ := Parse(&config.ServiceConfig{MaxHeaderBytes: 1 << 20}); len(rule(&s)) > 0 {
+		t.Error("false positive")
+	}
+	if s, _ := Parse(&config.ServiceConfig{}); !reflect.DeepEqual(rule(&s), []Location{serviceLocation()}) {
+		t.Errorf("unexpected locations: %v", rule(&s))
+	}
+
+	s, _ := Parse(&config.ServiceConfig{MaxHeaderBytes: 1<<20 + 1})
+	want := serviceLocation()
+	want.Detail = "max_header_bytes: 1048577"
+	if ls := rule(&s); !reflect.DeepEqual(ls, []Location{want}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasCatchAllWithoutMethod(t *testing.T) {
+	src := `{"version": 3, "endpoints": [
+		{"endpoint": "/foo", "backend": [{"host": ["http://a"], "url_pattern": "/a"}]},
+		{"endpoint": "/foo/*", "method": "GET", "backend": [{"host": ["http://a"], "url_pattern": "/a"}]},
+		{"endpoint": "/bar/*", "backend": [{"host": ["http://a"], "url_pattern": "/a"}]},
+		{"endpoint": "/__catchall", "method": "", "backend": [{"host": ["http://a"], "url_pattern": "/a"}]}
+	]}`
+	p := filepath.Join(t.TempDir(), "krakend.json")
+	if err := os.WriteFile(p, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewParser().Parse(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the parser defaults the method to GET, so the decoded configuration never triggers the rule
+	s, _ := Parse(&cfg)
+	if ls := hasCatchAllWithoutMethod(&s); len(ls) > 0 {
+		t.Errorf("unexpected locations for the decoded configuration: %v", ls)
+	}
+
+	s, _, err = parseJSON(p, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ls := hasCatchAllWithoutMethod(&s); !reflect.DeepEqual(ls, []Location{endpointLocation(2), endpointLocation(3)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasUnsanitizedBackendHost(t *testing.T) {
+	backends := []*config.Backend{
+		{Host: []string{"http://example.com"}},
+		{Host: []string{"http://example.com"}, HostSanitizationDisabled: true},
+		{Host: []string{"foo.service.consul"}, SD: "dns", HostSanitizationDisabled: true},
+	}
+	s, _ := Parse(&config.ServiceConfig{
+		Endpoints:   []*config.EndpointConfig{{Endpoint: "/foo", Backend: backends}},
+		AsyncAgents: []*config.AsyncAgent{{Name: "agent", Backend: backends[1:]}},
+	})
+	if ls := hasUnsanitizedBackendHost(&s); !reflect.DeepEqual(ls, []Location{backendLocation(0, 1), agentBackendLocation(0, 0)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+}
+
+func Test_hasEmptyEndpoint(t *testing.T) {
+	empty := &config.Backend{Method: "GET"}
+	s, _ := Parse(&config.ServiceConfig{Endpoints: []*config.EndpointConfig{
+		{Endpoint: "/a"},
+		{Endpoint: "/b", Backend: []*config.Backend{empty, {URLPattern: "/"}}},
+		{Endpoint: "/c", Backend: []*config.Backend{empty, {URLPattern: "/c"}}},
+		{Endpoint: "/d", Backend: []*config.Backend{{URLPattern: "/d", Host: []string{"http://example.com"}}}},
+		{Endpoint: "/e", Backend: []*config.Backend{{ExtraConfig: config.ExtraConfig{"backend/static-filesystem": map[string]interface{}{}}}}},
+		{Endpoint: "/f", Backend: []*config.Backend{empty}, ExtraConfig: config.ExtraConfig{"proxy": map[string]interface{}{"static": map[string]interface{}{}}}},
+		{Endpoint: "/g", Backend: []*config.Backend{{Mapping: map[string]string{"a": "b"}}}},
+	}})
+	if ls := hasEmptyEndpoint(&s); !reflect.DeepEqual(ls, []Location{endpointLocation(1)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+	if ls := hasUnroutableBackendHost(&s); !reflect.DeepEqual(ls, []Location{backendLocation(2, 0), backendLocation(2, 1), backendLocation(4, 0), backendLocation(5, 0), backendLocation(6, 0)}) {
+		t.Errorf("unexpected unroutable locations: %v", ls)
+	}
+
+	// the parser copies the host of the service into the backends without their own
+	src := `{"version": 3, "host": ["http://example.com"], "endpoints": [
+		{"endpoint": "/a", "backend": [{"@comment": "url_pattern: /a"}]},
+		{"endpoint": "/b", "backend": [{"url_pattern": "/b"}]}
+	]}`
+	p := filepath.Join(t.TempDir(), "krakend.json")
+	if err := os.WriteFile(p, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewParser().Parse(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ = Parse(&cfg)
+	if ls := hasEmptyEndpoint(&s); len(ls) > 0 {
+		t.Errorf("unexpected locations for the decoded configuration: %v", ls)
+	}
+
+	s, _, err = parseJSON(p, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ls := hasEmptyEndpoint(&s); !reflect.DeepEqual(ls, []Location{endpointLocation(0)}) {
+		t.Errorf("unexpected locations: %v", ls)
+	}
+	if ls := hasUnroutableBackendHost(&s); len(ls) > 0 {
+		t.Errorf("unexpected unroutable locations: %v", ls)
+	}
+}
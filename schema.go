@@ -0,0 +1,49 @@
+package audit
+
+// resultSchema describes the JSON encoding of AuditResult. Every property matches the json tag of
+// a field of AuditResult, Recommendation or Stats
+const resultSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://www.krakend.io/schema/audit-result.json",
+  "title": "KrakenD audit result",
+  "type": "object",
+  "required": ["recommendations", "stats"],
+  "additionalProperties": false,
+  "properties": {
+    "recommendations": {
+      "type": "array",
+      "items": {"$ref": "#/$defs/recommendation"}
+    },
+    "stats": {"$ref": "#/$defs/stats"}
+  },
+  "$defs": {
+    "recommendation": {
+      "type": "object",
+      "required": ["rule", "severity", "message"],
+      "additionalProperties": false,
+      "properties": {
+        "rule": {"type": "string", "description": "id of the rule"},
+        "severity": {"type": "string", "enum": ["CRITICAL", "HIGH", "MEDIUM", "LOW"]},
+        "message": {"type": "string"},
+        "link": {"type": "string", "description": "documentation of the recommendation"},
+        "location": {"type": "string", "description": "element of the configuration where the rule applies"},
+        "count": {"type": "integer", "minimum": 1, "description": "occurrences of a deduplicated recommendation"},
+        "locations": {"type": "array", "items": {"type": "string"}, "description": "locations of a deduplicated recommendation"},
+        "source": {"type": "string", "description": "name of the audited configuration"}
+      }
+    },
+    "stats": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "unknown_ignored": {"type": "array", "items": {"type": "string"}, "description": "ignored rule ids not matching any rule"}
+      }
+    }
+  }
+}
+`
+
+// ResultSchema returns the JSON Schema describing the JSON encoding of an AuditResult
+func ResultSchema() []byte {
+	return []byte(resultSchema)
+}
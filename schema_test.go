@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/luraproject/lura/v2/config"
+)
+
+func TestResultSchema_tags(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(ResultSchema(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	defs := schema["$defs"].(map[string]interface{})
+
+	for name, tc := range map[string]struct {
+		schema interface{}
+		typ    reflect.Type
+	}{
+		"result":         {schema, reflect.TypeOf(AuditResult{})},
+		"recommendation": {defs["recommendation"], reflect.TypeOf(Recommendation{})},
+		"stats":          {defs["stats"], reflect.TypeOf(Stats{})},
+	} {
+		var tags, properties []string
+		for i := 0; i < tc.typ.NumField(); i++ {
+			tags = append(tags, strings.Split(tc.typ.Field(i).Tag.Get("json"), ",")[0])
+		}
+		for p := range tc.schema.(map[string]interface{})["properties"].(map[string]interface{}) {
+			properties = append(properties, p)
+		}
+		sort.Strings(tags)
+		sort.Strings(properties)
+		if !reflect.DeepEqual(tags, properties) {
+			t.Errorf("%s: the schema is out of sync. tags: %v, properties: %v", name, tags, properties)
+		}
+	}
+}
+
+func TestResultSchema_validate(t *testing.T) {
+	cfg, err := config.NewParser().Parse("./tests/example1.json")
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	cfg.Normalize()
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(ResultSchema(), &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []AuditOptions{
+		{Severities: []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}, Ignore: []string{"foo"}},
+		{Severities: []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}, Dedupe: true},
+	} {
+		res, err := AuditWith(&cfg, opts)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		b, err := json.Marshal(res.WithSource("example1.json"))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var doc interface{}
+		if err := json.Unmarshal(b, &doc); err != nil {
+			t.Error(err)
+			continue
+		}
+		if err := validateSchema(schema, schema, doc, "$"); err != nil {
+			t.Error(err)
+		}
+	}
+
+	var doc interface{}
+	json.Unmarshal([]byte(`{"recommendations": [{"rule": "1.1.1", "severity": "URGENT", "message": "x"}], "stats": {}}`), &doc)
+	if err := validateSchema(schema, schema, doc, "$"); err == nil {
+		t.Error("expecting an error")
+	}
+}
+
+// validateSchema checks the doc against the subset of JSON Schema used by ResultSchema
+func validateSchema(root, schema map[string]interface{}, doc interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def := strings.TrimPrefix(ref, "#/$defs/")
+		return validateSchema(root, root["$defs"].(map[string]interface{})[def].(map[string]interface{}), doc, path)
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: object expected", path)
+		}
+		for _, r := range asSlice(schema["required"]) {
+			if _, ok := obj[r.(string)]; !ok {
+				return fmt.Errorf("%s: missing property %s", path, r)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for k, v := range obj {
+			p, ok := properties[k]
+			if !ok {
+				return fmt.Errorf("%s: unexpected property %s", path, k)
+			}
+			if err := validateSchema(root, p.(map[string]interface{}), v, path+"."+k); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := doc.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: array expected", path)
+		}
+		for i, v := range arr {
+			if err := validateSchema(root, schema["items"].(map[string]interface{}), v, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := doc.(string)
+		if !ok {
+			return fmt.Errorf("%s: string expected", path)
+		}
+		if enum := asSlice(schema["enum"]); len(enum) > 0 {
+			for _, e := range enum {
+				if e == s {
+					return nil
+				}
+			}
+			return fmt.Errorf("%s: unexpected value %q", path, s)
+		}
+	case "integer":
+		n, ok := doc.(float64)
+		if !ok || n != float64(int(n)) {
+			return fmt.Errorf("%s: integer expected", path)
+		}
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			return fmt.Errorf("%s: %v is lower than %v", path, n, min)
+		}
+	}
+	return nil
+}
+
+func asSlice(v interface{}) []interface{} {
+	res, _ := v.([]interface{})
+	return res
+}
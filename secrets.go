@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"encoding/base64"
+	"regexp"
+	"strings"
+)
+
+// secretPatterns are the heuristics used to detect hardcoded credentials. They are kept conservative
+// to avoid false positives:
+//   - a PEM private key block (-----BEGIN ... PRIVATE KEY-----)
+//   - an AWS access key id (AKIA followed by 16 uppercase letters or digits)
+//   - an api_key, apikey, access_token or client_secret parameter with a literal value (not a
+//     {placeholder}) of at least 8 chars
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`(?i)\b(api_?key|access_token|client_secret)=[^{&\s]{8,}`),
+}
+
+// looksLikeSecret checks if the string matches any of the secretPatterns or if it is a basic
+// authorization header (Basic <base64>) and its payload decodes to user:password
+func looksLikeSecret(s string) bool {
+	for _, p := range secretPatterns {
+		if p.MatchString(s) {
+			return true
+		}
+	}
+
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields)-1; i++ {
+		if !strings.EqualFold(fields[i], "Basic") {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(fields[i+1])
+		if err == nil && strings.Contains(string(b), ":") {
+			return true
+		}
+	}
+	return false
+}
+
+// containsSecret walks the raw configuration value looking for strings that look like secrets
+func containsSecret(v interface{}) bool {
+	switch t := v.(type) {
+	case string:
+		return looksLikeSecret(t)
+	case []interface{}:
+		for _, e := range t {
+			if containsSecret(e) {
+				return true
+			}
+		}
+	case []string:
+		for _, e := range t {
+			if looksLikeSecret(e) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, e := range t {
+			if containsSecret(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
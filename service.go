@@ -1,7 +1,19 @@
 package audit
 
 // Service represents a KrakenD configuration as a tree of bitsets representing
-// which components and flags are enabled at the KrakenD configuration
+// which components and flags are enabled at the KrakenD configuration. It is the result of Parse
+// and the input of every rule, so it can be used to write custom evaluators. The Details are:
+//
+//	0: flags of the service (see ServicePlugin and the following constants)
+//	1: max_idle_connections
+//	2: max_idle_connections_per_host
+//	3: min_version of the TLS config as a crypto/tls version, or 0 without TLS
+//	4: max_version of the TLS config as a crypto/tls version, or 0 without TLS
+//	5: timeout, in milliseconds
+//	6: max_header_bytes
+//
+// The Components map the namespaces of the extra_config with a summary of their settings. The
+// summary depends on the component and it is empty for the unknown ones
 type Service struct {
 	Details    []int      `json:"d"`
 	Agents     []Agent    `json:"a"`
@@ -27,7 +39,15 @@ func (s Service) Clone() Service {
 	return res
 }
 
-// Agent captures details of the AsyncAgents present at the configuration
+// Agent captures details of the AsyncAgents present at the configuration. The Details are:
+//
+//	0: encoding (see EncodingNOOP and the following constants)
+//	1: consumer workers
+//	2: connection max_retries
+//	3: consumer timeout, in milliseconds
+//	4: consumer max_rate, rounded up
+//	5: flags of the agent (see AgentBackoffStrategy)
+//	6: 1 + the index of the first previous agent with the same name, or 0 if the name is unique
 type Agent struct {
 	Details    []int     `json:"d"`
 	Backends   []Backend `json:"b"`
@@ -48,7 +68,19 @@ func (a Agent) Clone() Agent {
 	return res
 }
 
-// Endpoint captures details of the endpoints present at the configuration
+// Endpoint captures details of the endpoints present at the configuration. The Details are:
+//
+//	0: output encoding (see EncodingNOOP and the following constants)
+//	1: number of input_query_strings
+//	2: number of input_headers
+//	3: timeout, in milliseconds
+//	4: wildcards (see BitEndpointWildcard and the following constants)
+//	5: number of backends called with unsafe methods
+//	6: flags of the endpoint, including its method (see MethodGET and EndpointInputHeaderContentType)
+//	7: cache_ttl, in milliseconds
+//	8: number of different backend hostnames
+//	9: concurrent_calls
+//	10: 1 + the index of the first previous endpoint overlapping it, or 0 if there is none
 type Endpoint struct {
 	Details    []int     `json:"d"`
 	Backends   []Backend `json:"b"`
@@ -69,7 +101,10 @@ func (e Endpoint) Clone() Endpoint {
 	return res
 }
 
-// Backend captures details of the backends present at the configuration
+// Backend captures details of the backends present at the configuration. The Details are:
+//
+//	0: flags of the backend, including its encoding (see EncodingNOOP and BackendAllow)
+//	1: highest max_retries of the components retrying with a linear backoff_strategy
 type Backend struct {
 	Details    []int     `json:"d"`
 	Components Component `json:"c"`
@@ -112,6 +147,18 @@ const (
 	ServiceEcho
 	ServiceUseH2C
 	ServiceTLSPrivPubKey
+	ServiceTimeout
+	ServiceTLSWeakCipherSuites
+	ServiceDuplicatedNamespace
+	ServiceHasName
+	ServiceReadTimeout
+	ServiceWriteTimeout
+	ServiceIdleTimeout
+)
+
+const (
+	AgentBackoffStrategy = iota
+	AgentDuplicatedNamespace
 )
 
 const (
@@ -124,6 +171,28 @@ const (
 	EncodingOther
 )
 
+const (
+	MethodGET = iota
+	MethodHEAD
+	MethodPOST
+	MethodPUT
+	MethodPATCH
+	MethodDELETE
+	MethodOther
+)
+
+const (
+	EndpointInputHeaderContentType = iota + MethodOther + 1
+	EndpointMethodWildcard
+	EndpointCacheComponent
+	EndpointTimeoutMissing
+	EndpointHealthCheck
+	EndpointDuplicatedNamespace
+	EndpointInputHeaderAuthorization
+	EndpointReturnErrorDetails
+	EndpointMethodMissing
+)
+
 const (
 	BackendAllow = iota + EncodingOther + 1
 	BackendDeny
@@ -133,6 +202,19 @@ const (
 	BackendIsCollection
 	BackendHeadersToPass
 	BackendQuery
+	BackendURLWithJWTClaim
+	BackendHardcodedSecret
+	BackendRetryWithoutBackoff
+	BackendQueryStringWildcard
+	BackendMethodGET
+	BackendUnroutableHost
+	BackendSDDNS
+	BackendHostSanitizationDisabled
+	BackendSensitiveConnector
+	BackendAMQPAutoAck
+	BackendDuplicatedNamespace
+	BackendHeadersWildcard
+	BackendEmpty
 )
 
 const (
@@ -162,3 +244,56 @@ const (
 	BackendComponentHTTPClientAllowInsecureConnections
 	BackendComponentHTTPClientCerts
 )
+
+const (
+	CORSAllowOriginsWildcard = iota
+	CORSAllowCredentials
+	CORSAllowMethodsPermissive
+	CORSAllowHeadersWildcard
+	CORSAllowHeadersEmpty
+)
+
+const (
+	CircuitBreakerMaxErrors = iota
+)
+
+const (
+	ValidatorAlgNone = iota
+	ValidatorAlgHMAC
+	ValidatorJWKURLInsecure
+	ValidatorJWKURLInsecureLoopback
+	ValidatorIssuer
+	ValidatorAudience
+	ValidatorJWKRemote
+	ValidatorJWKCache
+)
+
+const (
+	HTTPSecureIsDevelopment = iota
+)
+
+const (
+	GRPCServerReflection = iota
+)
+
+const (
+	LoggingLevelDebug = iota
+	LoggingStdout
+	LoggingSyslog
+)
+
+const (
+	OpenCensusLogger = iota
+	OpenCensusZipkin
+	OpenCensusJaeger
+	OpenCensusInfluxDB
+	OpenCensusPrometheus
+	OpenCensusXRay
+	OpenCensusStackdriver
+	OpenCensusDatadog
+	OpenCensusOCAgent
+)
+
+const (
+	StaticFilesystemDirectoryListing = iota
+)
@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Thresholds groups the limits of the numeric rules. The zero value of a field stands for its
+// value in DefaultThresholds, so callers only need to set the limits they want to change
+type Thresholds struct {
+	// TimeoutLow, TimeoutMedium, TimeoutHigh and TimeoutCritical delimit the timeout tiers: an
+	// endpoint timeout above TimeoutLow and up to TimeoutMedium is reported by 3.3.1, up to
+	// TimeoutHigh by 3.3.2, up to TimeoutCritical by 3.3.3 and above it by 3.3.4
+	TimeoutLow      time.Duration
+	TimeoutMedium   time.Duration
+	TimeoutHigh     time.Duration
+	TimeoutCritical time.Duration
+	// MaxIdleConnections is the highest max_idle_connections and max_idle_connections_per_host
+	// accepted by 2.4.1
+	MaxIdleConnections int
+	// MaxCircuitBreakerTimeout is the longest time a circuit breaker can stay open (3.1.8)
+	MaxCircuitBreakerTimeout time.Duration
+	// MaxLinearRetries is the highest max_retries of a backend with a linear backoff_strategy
+	// accepted by 3.2.1
+	MaxLinearRetries int
+	// MaxConcurrentCalls is the highest concurrent_calls accepted by 5.2.5
+	MaxConcurrentCalls int
+	// MaxBackendsPerEndpoint is the highest number of backends of an endpoint accepted by 5.2.6
+	MaxBackendsPerEndpoint int
+	// MaxAgentWorkers is the highest number of consumer workers of an async agent accepted by 6.1.4
+	MaxAgentWorkers int
+	// ServiceTimeoutRatio is how many times the service timeout can exceed the longest endpoint
+	// timeout before 3.3.6 reports it
+	ServiceTimeoutRatio int
+	// MaxHeaderBytes is the highest max_header_bytes of the server accepted by 2.4.4
+	MaxHeaderBytes int
+}
+
+// DefaultThresholds are the limits used by the audit unless AuditOptions.Thresholds says otherwise.
+// The idle connections leave room above the default of 250 per host and the header bytes match the
+// default of the Go HTTP server
+var DefaultThresholds = Thresholds{
+	TimeoutLow:               3 * time.Second,
+	TimeoutMedium:            5 * time.Second,
+	TimeoutHigh:              30 * time.Second,
+	TimeoutCritical:          time.Minute,
+	MaxIdleConnections:       1000,
+	MaxCircuitBreakerTimeout: 10 * time.Minute,
+	MaxLinearRetries:         5,
+	MaxConcurrentCalls:       3,
+	MaxBackendsPerEndpoint:   5,
+	MaxAgentWorkers:          20,
+	ServiceTimeoutRatio:      5,
+	MaxHeaderBytes:           1 << 20,
+}
+
+// withDefaults returns a copy of the thresholds where the zero values are replaced by the defaults
+func (t Thresholds) withDefaults() Thresholds {
+	d := DefaultThresholds
+	if t.TimeoutLow > 0 {
+		d.TimeoutLow = t.TimeoutLow
+	}
+	if t.TimeoutMedium > 0 {
+		d.TimeoutMedium = t.TimeoutMedium
+	}
+	if t.TimeoutHigh > 0 {
+		d.TimeoutHigh = t.TimeoutHigh
+	}
+	if t.TimeoutCritical > 0 {
+		d.TimeoutCritical = t.TimeoutCritical
+	}
+	if t.MaxIdleConnections > 0 {
+		d.MaxIdleConnections = t.MaxIdleConnections
+	}
+	if t.MaxCircuitBreakerTimeout > 0 {
+		d.MaxCircuitBreakerTimeout = t.MaxCircuitBreakerTimeout
+	}
+	if t.MaxLinearRetries > 0 {
+		d.MaxLinearRetries = t.MaxLinearRetries
+	}
+	if t.MaxConcurrentCalls > 0 {
+		d.MaxConcurrentCalls = t.MaxConcurrentCalls
+	}
+	if t.MaxBackendsPerEndpoint > 0 {
+		d.MaxBackendsPerEndpoint = t.MaxBackendsPerEndpoint
+	}
+	if t.MaxAgentWorkers > 0 {
+		d.MaxAgentWorkers = t.MaxAgentWorkers
+	}
+	if t.ServiceTimeoutRatio > 0 {
+		d.ServiceTimeoutRatio = t.ServiceTimeoutRatio
+	}
+	if t.MaxHeaderBytes > 0 {
+		d.MaxHeaderBytes = t.MaxHeaderBytes
+	}
+	return d
+}
+
+// validate checks that the timeout tiers are sorted
+func (t Thresholds) validate() error {
+	if t.TimeoutLow >= t.TimeoutMedium || t.TimeoutMedium >= t.TimeoutHigh || t.TimeoutHigh >= t.TimeoutCritical {
+		return fmt.Errorf("audit: the timeout thresholds must be increasing: %s, %s, %s, %s", t.TimeoutLow, t.TimeoutMedium, t.TimeoutHigh, t.TimeoutCritical)
+	}
+	return nil
+}
+
+// humanDuration describes a duration the way the rule messages do, as in "3 seconds" or "1 minute"
+func humanDuration(d time.Duration) string {
+	unit, n := "second", int64(d/time.Second)
+	switch {
+	case d%time.Second != 0:
+		return d.String()
+	case d >= time.Minute && d%time.Minute == 0:
+		unit, n = "minute", int64(d/time.Minute)
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+// ms returns a duration in milliseconds, the unit of the timeouts stored in the endpoint details
+func ms(d time.Duration) int {
+	return int(d / time.Millisecond)
+}
@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThresholds_withDefaults(t *testing.T) {
+	if got := (Thresholds{}).withDefaults(); got != DefaultThresholds {
+		t.Errorf("unexpected thresholds: %+v", got)
+	}
+
+	got := Thresholds{TimeoutLow: time.Second, MaxAgentWorkers: 5, ServiceTimeoutRatio: 2, MaxHeaderBytes: 8192}.withDefaults()
+	want := DefaultThresholds
+	want.TimeoutLow = time.Second
+	want.MaxAgentWorkers = 5
+	want.ServiceTimeoutRatio = 2
+	want.MaxHeaderBytes = 8192
+	if got != want {
+		t.Errorf("unexpected thresholds: %+v", got)
+	}
+}
+
+func TestThresholds_validate(t *testing.T) {
+	if err := DefaultThresholds.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	th := Thresholds{TimeoutLow: 10 * time.Second}.withDefaults()
+	err := th.validate()
+	if err == nil || err.Error() != "audit: the timeout thresholds must be increasing: 10s, 5s, 30s, 1m0s" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewAuditorWith(AuditOptions{Thresholds: &th}); err == nil {
+		t.Error("expecting an error")
+	}
+}
+
+func Test_humanDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		time.Second:             "1 second",
+		3 * time.Second:         "3 seconds",
+		90 * time.Second:        "90 seconds",
+		time.Minute:             "1 minute",
+		10 * time.Minute:        "10 minutes",
+		1500 * time.Millisecond: "1.5s",
+	} {
+		if got := humanDuration(d); got != want {
+			t.Errorf("%s: unexpected description %q", d, got)
+		}
+	}
+}
+
+func TestAuditor_thresholds(t *testing.T) {
+	s := &Service{
+		Details: []int{0, 0, 0, 0, 0},
+		Endpoints: []Endpoint{
+			{Details: []int{0, 0, 0, 2000, 0, 0, 0, 0, 0, 0, 0}},
+			{Details: []int{0, 0, 0, 4000, 0, 0, 0, 0, 0, 0, 0}},
+		},
+	}
+	timeoutRules := func(opts AuditOptions) map[string]string {
+		a, err := NewAuditorWith(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ar, err := a.AuditService(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := map[string]string{}
+		for _, r := range ar.Recommendations {
+			if len(r.Rule) == 5 && r.Rule[:4] == "3.3." {
+				res[r.Rule] = r.Message
+			}
+		}
+		return res
+	}
+
+	got := timeoutRules(AuditOptions{Severities: AllSeverities})
+	if len(got) != 1 || got["3.3.1"] != "Set timeouts to below 3 seconds for improved performance." {
+		t.Errorf("unexpected recommendations: %v", got)
+	}
+
+	got = timeoutRules(AuditOptions{Severities: AllSeverities, Thresholds: &Thresholds{TimeoutLow: time.Second, TimeoutMedium: 3 * time.Second}})
+	if len(got) != 2 ||
+		got["3.3.1"] != "Set timeouts to below 1 second for improved performance." ||
+		got["3.3.2"] != "Set timeouts to below 3 seconds for improved performance." {
+		t.Errorf("unexpected recommendations: %v", got)
+	}
+}